@@ -1,6 +1,7 @@
 package add
 
 import (
+	"github.com/it2911/kubectl-cfg/pkg/cmd/merge"
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/tools/clientcmd"
@@ -9,7 +10,7 @@ import (
 )
 
 var (
-	addLong = templates.LongDesc(`Create context / cluster / authinfo into the kubeconfig file.`)
+	addLong = templates.LongDesc(`Create context / cluster / authinfo into the kubeconfig file, or import them from another kubeconfig file.`)
 
 	addExample = templates.Examples(`
 		# Add resources into your kubeconfig file
@@ -26,10 +27,13 @@ func NewCmdCfgAdd(streams genericclioptions.IOStreams, configAccess clientcmd.Co
 		Example:               addExample,
 		Run:                   cmdutil.DefaultSubCommandRun(streams.ErrOut),
 	}
-	//cmd.AddCommand(merge.NewCmdCfgAddConfig(cmdutil.NewFactory(genericclioptions.NewTestConfigFlags()), streams, configAccess))
-	cmd.AddCommand(NewCmdCfgAddContext(streams.Out, configAccess))
-	cmd.AddCommand(NewCmdCfgAddCluster(streams.Out, configAccess))
-	cmd.AddCommand(NewCmdCfgAddAuthInfo(streams.Out, configAccess))
+	cmd.AddCommand(merge.NewCmdCfgAddConfig(streams, configAccess))
+	cmd.AddCommand(merge.NewCmdCfgAddSecret(streams, configAccess))
+	cmd.AddCommand(merge.NewCmdCfgAddFromProvider(streams, configAccess))
+	cmd.AddCommand(merge.NewCmdCfgAddLocal(streams, configAccess))
+	cmd.AddCommand(NewCmdCfgAddContext(streams, configAccess))
+	cmd.AddCommand(NewCmdCfgAddCluster(streams, configAccess))
+	cmd.AddCommand(NewCmdCfgAddAuthInfo(streams, configAccess))
 
 	return cmd
 }
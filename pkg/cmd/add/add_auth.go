@@ -5,6 +5,7 @@ import (
 	"io"
 
 	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/tools/clientcmd"
 	kconf "k8s.io/kubectl/pkg/cmd/config"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
@@ -27,7 +28,13 @@ var (
 		    Basic auth flags:
 			  --%v=basic_user --%v=basic_password
 
-		Bearer token and basic auth are mutually exclusive.`), clientcmd.FlagCertFile, clientcmd.FlagKeyFile, clientcmd.FlagBearerToken, clientcmd.FlagUsername, clientcmd.FlagPassword)
+		Bearer token and basic auth are mutually exclusive.
+
+		The client certificate and key must be PEM files and the key must match the certificate when
+		both are given. The token must be given without its "Bearer " prefix.
+
+		Run on a terminal without a name, the command asks for the user and its authentication method
+		step by step.`), clientcmd.FlagCertFile, clientcmd.FlagKeyFile, clientcmd.FlagBearerToken, clientcmd.FlagUsername, clientcmd.FlagPassword)
 
 	createAuthInfoExample = templates.Examples(`
 		# Set only the "client-key" field on the "AUTHINFO_NAME"
@@ -63,12 +70,12 @@ var (
 )
 
 // NewCmdConfigSetAuthInfo returns an Command option instance for 'config set-credentials' sub command
-func NewCmdCfgAddAuthInfo(out io.Writer, configAccess clientcmd.ConfigAccess) *cobra.Command {
+func NewCmdCfgAddAuthInfo(streams genericclioptions.IOStreams, configAccess clientcmd.ConfigAccess) *cobra.Command {
 	options := &kconf.CreateAuthInfoOptions{ConfigAccess: configAccess}
-	return newCmdCfgAddAuthInfo(out, options)
+	return newCmdCfgAddAuthInfo(streams, options)
 }
 
-func newCmdCfgAddAuthInfo(out io.Writer, options *kconf.CreateAuthInfoOptions) *cobra.Command {
+func newCmdCfgAddAuthInfo(streams genericclioptions.IOStreams, options *kconf.CreateAuthInfoOptions) *cobra.Command {
 	cmd := &cobra.Command{
 		Use: fmt.Sprintf(
 			"auth AUTHINFO_NAME [--%v=path/to/certfile] "+
@@ -99,13 +106,18 @@ func newCmdCfgAddAuthInfo(out io.Writer, options *kconf.CreateAuthInfoOptions) *
 		Long:                  createAuthInfoLong,
 		Example:               createAuthInfoExample,
 		Run: func(cmd *cobra.Command, args []string) {
-			err := options.Complete(cmd, out)
+			if len(args) == 0 && isInteractive(streams.In) {
+				cmdutil.CheckErr(runGuide(streams, options.ConfigAccess, (*guide).authInfo))
+				return
+			}
+			err := options.Complete(cmd, streams.Out)
 			if err != nil {
 				cmd.Help()
 				cmdutil.CheckErr(err)
 			}
+			cmdutil.CheckErr(validateAuthInfo(options, streams.ErrOut))
 			cmdutil.CheckErr(options.Run())
-			fmt.Fprintf(out, "User %q set.\n", options.Name)
+			fmt.Fprintf(streams.Out, "User %q set.\n", options.Name)
 		},
 	}
 
@@ -127,3 +139,34 @@ func newCmdCfgAddAuthInfo(out io.Writer, options *kconf.CreateAuthInfoOptions) *
 
 	return cmd
 }
+
+// validateAuthInfo checks the client certificate, the client key and the token before they are written.
+func validateAuthInfo(o *kconf.CreateAuthInfoOptions, errOut io.Writer) error {
+	certFile, keyFile := o.ClientCertificate.Value(), o.ClientKey.Value()
+	if len(certFile) != 0 {
+		if err := validateCertificateFile(certFile); err != nil {
+			return err
+		}
+	}
+	switch {
+	case len(certFile) != 0 && len(keyFile) != 0:
+		if err := validateKeyPair(certFile, keyFile); err != nil {
+			return err
+		}
+	case len(keyFile) != 0:
+		if err := validateKeyFile(keyFile); err != nil {
+			return err
+		}
+	}
+
+	// an empty token clears the token of the user
+	if token := o.Token.Value(); len(token) != 0 {
+		if err := validateToken(token); err != nil {
+			return err
+		}
+		if warning := tokenWarning(token); len(warning) != 0 {
+			fmt.Fprintln(errOut, warning)
+		}
+	}
+	return nil
+}
@@ -1,57 +1,193 @@
 package add
 
 import (
+	"errors"
 	"fmt"
-	"io"
 
 	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/tools/clientcmd"
-	kubectlconfig "k8s.io/kubectl/pkg/cmd/config"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	cliflag "k8s.io/component-base/cli/flag"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/util/i18n"
 	"k8s.io/kubectl/pkg/util/templates"
 )
 
+// flagCAData is the flag of the certificate authority given as data.
+const flagCAData = "certificate-authority-data"
+
 var (
 	addClusterLong = templates.LongDesc(`
 		Sets a cluster entry in kubeconfig.
-		Specifying a name that already exists will merge new fields on top of existing values for those fields.`)
+		Specifying a name that already exists will merge new fields on top of existing values for those fields.
+
+		The server must be an https URL and the certificate authority must hold PEM certificates, given
+		as a file with --certificate-authority or as data with --certificate-authority-data, either
+		base64 encoded like in a kubeconfig or read from the standard input with "-".
+
+		Run on a terminal without a name, the command asks for the cluster step by step.`)
 
 	addClusterExample = templates.Examples(`
 		# Set only the server field on the CLUSTER_NAME cluster entry without touching other values.
 		kubectl cfg add cluster CLUSTER_NAME --server=https://1.2.3.4
 		# Embed certificate authority data for the CLUSTER_NAME cluster entry
 		kubectl cfg add cluster CLUSTER_NAME --certificate-authority=~/.kube/e2e/kubernetes.ca.crt
+		# Set the certificate authority of the CLUSTER_NAME cluster entry from a secret
+		kubectl get secret ca -o jsonpath='{.data.ca\.crt}' | kubectl cfg add cluster CLUSTER_NAME --certificate-authority-data=-
 		# Disable cert checking for the dev cluster entry
 		kubectl cfg add cluster CLUSTER_NAME --insecure-skip-tls-verify=true`)
 )
 
-// NewCmdConfigSetCluster returns a Command instance for 'config set-cluster' sub command
-func NewCmdCfgAddCluster(out io.Writer, configAccess clientcmd.ConfigAccess) *cobra.Command {
-	options := &kubectlconfig.CreateClusterOptions{ConfigAccess: configAccess}
+// AddClusterOptions contains the assignable options from the args.
+type AddClusterOptions struct {
+	configAccess             clientcmd.ConfigAccess
+	name                     string
+	server                   cliflag.StringFlag
+	insecureSkipTLSVerify    cliflag.Tristate
+	certificateAuthority     cliflag.StringFlag
+	certificateAuthorityData cliflag.StringFlag
+	embedCAData              cliflag.Tristate
+
+	caData []byte
+
+	genericclioptions.IOStreams
+}
+
+// NewCmdCfgAddCluster returns a Command instance for 'cfg add cluster' sub command
+func NewCmdCfgAddCluster(streams genericclioptions.IOStreams, configAccess clientcmd.ConfigAccess) *cobra.Command {
+	options := &AddClusterOptions{configAccess: configAccess, IOStreams: streams}
 
 	cmd := &cobra.Command{
-		Use:                   fmt.Sprintf("cluster NAME [--%v=server] [--%v=path/to/certificate/authority] [--%v=true]", clientcmd.FlagAPIServer, clientcmd.FlagCAFile, clientcmd.FlagInsecure),
+		Use:                   fmt.Sprintf("cluster NAME [--%v=server] [--%v=path/to/certificate/authority | --%v=base64|-] [--%v=true]", clientcmd.FlagAPIServer, clientcmd.FlagCAFile, flagCAData, clientcmd.FlagInsecure),
 		DisableFlagsInUseLine: true,
 		Short:                 i18n.T("Sets a cluster entry in kubeconfig"),
 		Long:                  addClusterLong,
 		Example:               addClusterExample,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(options.Complete(cmd))
-			cmdutil.CheckErr(options.Run())
-			fmt.Fprintf(out, "Cluster %q set.\n", options.Name)
+			if len(args) == 0 && isInteractive(streams.In) {
+				cmdutil.CheckErr(runGuide(streams, configAccess, (*guide).cluster))
+				return
+			}
+			cmdutil.CheckErr(options.Complete(cmd, args))
+			cmdutil.CheckErr(options.Validate())
+			cmdutil.CheckErr(options.RunAddCluster())
+			fmt.Fprintf(streams.Out, "Cluster %q set.\n", options.name)
 		},
 	}
 
-	options.InsecureSkipTLSVerify.Default(false)
+	options.insecureSkipTLSVerify.Default(false)
 
-	cmd.Flags().Var(&options.Server, clientcmd.FlagAPIServer, clientcmd.FlagAPIServer+" for the cluster entry in kubeconfig")
-	f := cmd.Flags().VarPF(&options.InsecureSkipTLSVerify, clientcmd.FlagInsecure, "", clientcmd.FlagInsecure+" for the cluster entry in kubeconfig")
+	cmd.Flags().Var(&options.server, clientcmd.FlagAPIServer, clientcmd.FlagAPIServer+" for the cluster entry in kubeconfig")
+	f := cmd.Flags().VarPF(&options.insecureSkipTLSVerify, clientcmd.FlagInsecure, "", clientcmd.FlagInsecure+" for the cluster entry in kubeconfig")
 	f.NoOptDefVal = "true"
-	cmd.Flags().Var(&options.CertificateAuthority, clientcmd.FlagCAFile, "Path to "+clientcmd.FlagCAFile+" file for the cluster entry in kubeconfig")
+	cmd.Flags().Var(&options.certificateAuthority, clientcmd.FlagCAFile, "Path to "+clientcmd.FlagCAFile+" file for the cluster entry in kubeconfig")
 	cmd.MarkFlagFilename(clientcmd.FlagCAFile)
-	f = cmd.Flags().VarPF(&options.EmbedCAData, clientcmd.FlagEmbedCerts, "", clientcmd.FlagEmbedCerts+" for the cluster entry in kubeconfig")
+	cmd.Flags().Var(&options.certificateAuthorityData, flagCAData, "Base64 encoded certificate authority for the cluster entry in kubeconfig, or - to read it from the standard input")
+	f = cmd.Flags().VarPF(&options.embedCAData, clientcmd.FlagEmbedCerts, "", clientcmd.FlagEmbedCerts+" for the cluster entry in kubeconfig")
 	f.NoOptDefVal = "true"
 
 	return cmd
 }
+
+// Complete assigns AddClusterOptions from the args, and reads --certificate-authority-data.
+func (o *AddClusterOptions) Complete(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmdutil.UsageErrorf(cmd, "unexpected args: %v", args)
+	}
+	o.name = args[0]
+
+	if o.certificateAuthorityData.Provided() && len(o.certificateAuthorityData.Value()) != 0 {
+		data, err := readCertificateAuthorityData(o.certificateAuthorityData.Value(), o.In)
+		if err != nil {
+			return err
+		}
+		o.caData = data
+	}
+	return nil
+}
+
+// Validate checks the server and the certificate authority, and warns about insecure mode.
+func (o *AddClusterOptions) Validate() error {
+	if len(o.name) == 0 {
+		return errors.New("you must specify a non-empty cluster name")
+	}
+	if o.server.Provided() {
+		if err := validateServer(o.server.Value()); err != nil {
+			return err
+		}
+	}
+
+	hasCA := len(o.certificateAuthority.Value()) != 0 || len(o.caData) != 0
+	if len(o.certificateAuthority.Value()) != 0 && len(o.caData) != 0 {
+		return fmt.Errorf("you cannot specify both --%s and --%s", clientcmd.FlagCAFile, flagCAData)
+	}
+	if o.insecureSkipTLSVerify.Value() && hasCA {
+		return errors.New("you cannot specify a certificate authority and insecure mode at the same time")
+	}
+	if o.embedCAData.Value() && len(o.certificateAuthority.Value()) == 0 {
+		return fmt.Errorf("you must specify a --%s to embed", clientcmd.FlagCAFile)
+	}
+	if len(o.certificateAuthority.Value()) != 0 {
+		if err := validateCertificateFile(o.certificateAuthority.Value()); err != nil {
+			return err
+		}
+	}
+
+	if o.insecureSkipTLSVerify.Value() {
+		fmt.Fprintln(o.ErrOut, insecureWarning)
+	}
+	return nil
+}
+
+// RunAddCluster merges the flags on top of the cluster entry and writes it.
+func (o *AddClusterOptions) RunAddCluster() error {
+	config, err := o.configAccess.GetStartingConfig()
+	if err != nil {
+		return err
+	}
+
+	cluster, exists := config.Clusters[o.name]
+	if !exists {
+		cluster = clientcmdapi.NewCluster()
+	}
+	modifiedCluster, err := o.modifyCluster(*cluster)
+	if err != nil {
+		return err
+	}
+	config.Clusters[o.name] = &modifiedCluster
+
+	return clientcmd.ModifyConfig(o.configAccess, *config, true)
+}
+
+func (o *AddClusterOptions) modifyCluster(cluster clientcmdapi.Cluster) (clientcmdapi.Cluster, error) {
+	if o.server.Provided() {
+		cluster.Server = o.server.Value()
+	}
+	if o.insecureSkipTLSVerify.Provided() {
+		cluster.InsecureSkipTLSVerify = o.insecureSkipTLSVerify.Value()
+		// Specifying insecure mode clears any certificate authority
+		if cluster.InsecureSkipTLSVerify {
+			cluster.CertificateAuthority = ""
+			cluster.CertificateAuthorityData = nil
+		}
+	}
+	if o.certificateAuthority.Provided() {
+		path, data, err := fileOrData(o.certificateAuthority.Value(), o.embedCAData.Value())
+		if err != nil {
+			return cluster, err
+		}
+		cluster.CertificateAuthority, cluster.CertificateAuthorityData = path, data
+		// Specifying a certificate authority clears insecure mode
+		if len(path) != 0 || len(data) != 0 {
+			cluster.InsecureSkipTLSVerify = false
+		}
+	}
+	if o.certificateAuthorityData.Provided() {
+		cluster.CertificateAuthority, cluster.CertificateAuthorityData = "", o.caData
+		if len(o.caData) != 0 {
+			cluster.InsecureSkipTLSVerify = false
+		}
+	}
+	return cluster, nil
+}
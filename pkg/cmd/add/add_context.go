@@ -1,54 +1,324 @@
 package add
 
 import (
+	"errors"
 	"fmt"
 	"io"
 
 	"github.com/spf13/cobra"
 
+	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	cliflag "k8s.io/component-base/cli/flag"
 	kubectlconfig "k8s.io/kubectl/pkg/cmd/config"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/util/i18n"
 	"k8s.io/kubectl/pkg/util/templates"
 )
 
+// defaultExecAPIVersion is the API version of a new exec credential plugin when --exec-api-version is not given.
+const defaultExecAPIVersion = "client.authentication.k8s.io/v1beta1"
+
 var (
 	addContextLong = templates.LongDesc(`
 		Sets a context entry in kubeconfig
-		Specifying a name that already exists will merge new fields on top of existing values for those fields.`)
+		Specifying a name that already exists will merge new fields on top of existing values for those fields.
+
+		The cluster and the user of the context can be set in the same command: --server and
+		--certificate-authority create or update the cluster, --token, --client-certificate,
+		--client-key and --exec-command create or update the user. They are named after the context
+		unless --cluster or --user is given, and everything is written at once.
+
+		The cluster and the user which the context is pointed at must exist once the command is done.
+
+		Run on a terminal without a name, the command asks for the context, its cluster and its user
+		step by step.`)
 
 	addContextExample = templates.Examples(`
 		# Set the user field on the gce context entry without touching other values
-		kubectl cfg add context gce --user=cluster-admin`)
+		kubectl cfg add context gce --user=cluster-admin
+
+		# Add the prod context together with its cluster and its user
+		kubectl cfg add context prod --server=https://1.2.3.4:6443 --certificate-authority=./ca.crt --embed-certs --token=abcdef
+
+		# Add the eks context with its cluster and an exec plugin user
+		kubectl cfg add context eks --server=https://eks.example.com --exec-command=aws --exec-arg=eks,get-token,--cluster-name,prod`)
 )
 
-// NewCmdConfigSetContext returns a Command instance for 'config set-context' sub command
-func NewCmdCfgAddContext(out io.Writer, configAccess clientcmd.ConfigAccess) *cobra.Command {
-	options := &kubectlconfig.CreateContextOptions{ConfigAccess: configAccess}
+// AddContextOptions contains the assignable options from the args.
+type AddContextOptions struct {
+	configAccess clientcmd.ConfigAccess
+	name         string
+	currContext  bool
+	cluster      cliflag.StringFlag
+	authInfo     cliflag.StringFlag
+	namespace    cliflag.StringFlag
+
+	// the inline cluster
+	server               cliflag.StringFlag
+	certificateAuthority cliflag.StringFlag
+
+	// the inline user
+	token             cliflag.StringFlag
+	clientCertificate cliflag.StringFlag
+	clientKey         cliflag.StringFlag
+	execCommand       cliflag.StringFlag
+	execAPIVersion    cliflag.StringFlag
+	execArgs          []string
+
+	embedCerts bool
+}
+
+// NewCmdCfgAddContext returns a Command instance for 'cfg add context' sub command
+func NewCmdCfgAddContext(streams genericclioptions.IOStreams, configAccess clientcmd.ConfigAccess) *cobra.Command {
+	options := &AddContextOptions{configAccess: configAccess}
 
 	cmd := &cobra.Command{
-		Use:                   fmt.Sprintf("context [NAME | --current] [--%v=cluster_nickname] [--%v=user_nickname] [--%v=namespace]", clientcmd.FlagClusterName, clientcmd.FlagAuthInfoName, clientcmd.FlagNamespace),
+		Use:                   fmt.Sprintf("context [NAME | --current] [--%v=cluster_nickname] [--%v=user_nickname] [--%v=namespace] [--%v=server] [--%v=token]", clientcmd.FlagClusterName, clientcmd.FlagAuthInfoName, clientcmd.FlagNamespace, clientcmd.FlagAPIServer, clientcmd.FlagBearerToken),
 		DisableFlagsInUseLine: true,
 		Short:                 i18n.T("Sets a context entry in kubeconfig"),
 		Long:                  addContextLong,
 		Example:               addContextExample,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(options.Complete(cmd))
-			name, exists, err := options.Run()
-			cmdutil.CheckErr(err)
-			if exists {
-				fmt.Fprintf(out, "Context %q modified.\n", name)
-			} else {
-				fmt.Fprintf(out, "Context %q created.\n", name)
+			if len(args) == 0 && !options.currContext && isInteractive(streams.In) {
+				cmdutil.CheckErr(runGuide(streams, configAccess, (*guide).context))
+				return
 			}
+			cmdutil.CheckErr(options.Complete(cmd, args))
+			cmdutil.CheckErr(options.Validate())
+			cmdutil.CheckErr(options.RunAddContext(streams.Out))
 		},
 	}
 
-	cmd.Flags().BoolVar(&options.CurrContext, "current", options.CurrContext, "Modify the current context")
-	cmd.Flags().Var(&options.Cluster, clientcmd.FlagClusterName, clientcmd.FlagClusterName+" for the context entry in kubeconfig")
-	cmd.Flags().Var(&options.AuthInfo, clientcmd.FlagAuthInfoName, clientcmd.FlagAuthInfoName+" for the context entry in kubeconfig")
-	cmd.Flags().Var(&options.Namespace, clientcmd.FlagNamespace, clientcmd.FlagNamespace+" for the context entry in kubeconfig")
+	cmd.Flags().BoolVar(&options.currContext, "current", options.currContext, "Modify the current context")
+	cmd.Flags().Var(&options.cluster, clientcmd.FlagClusterName, clientcmd.FlagClusterName+" for the context entry in kubeconfig")
+	cmd.Flags().Var(&options.authInfo, clientcmd.FlagAuthInfoName, clientcmd.FlagAuthInfoName+" for the context entry in kubeconfig")
+	cmd.Flags().Var(&options.namespace, clientcmd.FlagNamespace, clientcmd.FlagNamespace+" for the context entry in kubeconfig")
+
+	cmd.Flags().Var(&options.server, clientcmd.FlagAPIServer, clientcmd.FlagAPIServer+" for the cluster entry of the context")
+	cmd.Flags().Var(&options.certificateAuthority, clientcmd.FlagCAFile, "Path to "+clientcmd.FlagCAFile+" file for the cluster entry of the context")
+	cmd.MarkFlagFilename(clientcmd.FlagCAFile)
+
+	cmd.Flags().Var(&options.token, clientcmd.FlagBearerToken, clientcmd.FlagBearerToken+" for the user entry of the context")
+	cmd.Flags().Var(&options.clientCertificate, clientcmd.FlagCertFile, "Path to "+clientcmd.FlagCertFile+" file for the user entry of the context")
+	cmd.MarkFlagFilename(clientcmd.FlagCertFile)
+	cmd.Flags().Var(&options.clientKey, clientcmd.FlagKeyFile, "Path to "+clientcmd.FlagKeyFile+" file for the user entry of the context")
+	cmd.MarkFlagFilename(clientcmd.FlagKeyFile)
+	cmd.Flags().Var(&options.execCommand, kubectlconfig.FlagExecCommand, "Command for the exec credential plugin for the user entry of the context")
+	cmd.Flags().Var(&options.execAPIVersion, kubectlconfig.FlagExecAPIVersion, "API version of the exec credential plugin for the user entry of the context")
+	cmd.Flags().StringSliceVar(&options.execArgs, kubectlconfig.FlagExecArg, options.execArgs, "Arguments for the exec credential plugin command for the user entry of the context")
+
+	cmd.Flags().BoolVar(&options.embedCerts, clientcmd.FlagEmbedCerts, options.embedCerts, "Embed the certificate authority, client certificate and client key into kubeconfig")
 
 	return cmd
 }
+
+// Complete assigns AddContextOptions from the args.
+func (o *AddContextOptions) Complete(cmd *cobra.Command, args []string) error {
+	if len(args) > 1 {
+		return cmdutil.UsageErrorf(cmd, "unexpected args: %v", args)
+	}
+	if len(args) == 1 {
+		o.name = args[0]
+	}
+	return nil
+}
+
+// Validate checks that the context is named once, and the inline server, files and token.
+func (o *AddContextOptions) Validate() error {
+	if len(o.name) == 0 && !o.currContext {
+		return errors.New("you must specify a non-empty context name or --current")
+	}
+	if len(o.name) > 0 && o.currContext {
+		return errors.New("you cannot specify both a context name and --current")
+	}
+	if o.server.Provided() {
+		if err := validateServer(o.server.Value()); err != nil {
+			return err
+		}
+	}
+	if caFile := o.certificateAuthority.Value(); len(caFile) != 0 {
+		if err := validateCertificateFile(caFile); err != nil {
+			return err
+		}
+	}
+	if certFile := o.clientCertificate.Value(); len(certFile) != 0 {
+		if err := validateCertificateFile(certFile); err != nil {
+			return err
+		}
+	}
+	if keyFile := o.clientKey.Value(); len(keyFile) != 0 {
+		validate := validateKeyFile
+		if certFile := o.clientCertificate.Value(); len(certFile) != 0 {
+			validate = func(keyFile string) error { return validateKeyPair(certFile, keyFile) }
+		}
+		if err := validate(keyFile); err != nil {
+			return err
+		}
+	}
+	if token := o.token.Value(); len(token) != 0 {
+		if err := validateToken(token); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hasInlineCluster tells whether the cluster of the context is set in the same command.
+func (o *AddContextOptions) hasInlineCluster() bool {
+	return o.server.Provided() || o.certificateAuthority.Provided()
+}
+
+// hasInlineAuthInfo tells whether the user of the context is set in the same command.
+func (o *AddContextOptions) hasInlineAuthInfo() bool {
+	return o.token.Provided() || o.clientCertificate.Provided() || o.clientKey.Provided() || o.execCommand.Provided() || o.execAPIVersion.Provided() || len(o.execArgs) != 0
+}
+
+// RunAddContext sets the context, and its cluster and user when they are given inline, with a single write of the kubeconfig.
+func (o *AddContextOptions) RunAddContext(out io.Writer) error {
+	config, err := o.configAccess.GetStartingConfig()
+	if err != nil {
+		return err
+	}
+
+	name := o.name
+	if o.currContext {
+		if len(config.CurrentContext) == 0 {
+			return errors.New("no current context is set")
+		}
+		name = config.CurrentContext
+	}
+
+	context, contextExists := config.Contexts[name]
+	if !contextExists {
+		context = clientcmdapi.NewContext()
+	}
+	modifiedContext := *context
+	if o.cluster.Provided() {
+		modifiedContext.Cluster = o.cluster.Value()
+	} else if o.hasInlineCluster() && len(modifiedContext.Cluster) == 0 {
+		modifiedContext.Cluster = name
+	}
+	if o.authInfo.Provided() {
+		modifiedContext.AuthInfo = o.authInfo.Value()
+	} else if o.hasInlineAuthInfo() && len(modifiedContext.AuthInfo) == 0 {
+		modifiedContext.AuthInfo = name
+	}
+	if o.namespace.Provided() {
+		modifiedContext.Namespace = o.namespace.Value()
+	}
+
+	messages := []string{}
+	if o.hasInlineCluster() {
+		cluster, exists := config.Clusters[modifiedContext.Cluster]
+		if !exists {
+			cluster = clientcmdapi.NewCluster()
+		}
+		modifiedCluster, err := o.modifyCluster(*cluster)
+		if err != nil {
+			return err
+		}
+		config.Clusters[modifiedContext.Cluster] = &modifiedCluster
+		messages = append(messages, entryMessage("Cluster", modifiedContext.Cluster, exists))
+	}
+	if o.hasInlineAuthInfo() {
+		authInfo, exists := config.AuthInfos[modifiedContext.AuthInfo]
+		if !exists {
+			authInfo = clientcmdapi.NewAuthInfo()
+		}
+		modifiedAuthInfo, err := o.modifyAuthInfo(*authInfo)
+		if err != nil {
+			return err
+		}
+		config.AuthInfos[modifiedContext.AuthInfo] = &modifiedAuthInfo
+		messages = append(messages, entryMessage("User", modifiedContext.AuthInfo, exists))
+	}
+
+	// only the references set by this command are checked, so that a broken context can still be edited
+	if _, ok := config.Clusters[modifiedContext.Cluster]; len(modifiedContext.Cluster) != 0 && modifiedContext.Cluster != context.Cluster && !ok {
+		return fmt.Errorf("cluster %q not found, create it with --%s or 'kubectl cfg add cluster %s'", modifiedContext.Cluster, clientcmd.FlagAPIServer, modifiedContext.Cluster)
+	}
+	if _, ok := config.AuthInfos[modifiedContext.AuthInfo]; len(modifiedContext.AuthInfo) != 0 && modifiedContext.AuthInfo != context.AuthInfo && !ok {
+		return fmt.Errorf("user %q not found, create it with --%s, --%s or --%s, or 'kubectl cfg add auth %s'", modifiedContext.AuthInfo, clientcmd.FlagBearerToken, clientcmd.FlagCertFile, kubectlconfig.FlagExecCommand, modifiedContext.AuthInfo)
+	}
+
+	config.Contexts[name] = &modifiedContext
+	messages = append(messages, entryMessage("Context", name, contextExists))
+
+	if err := clientcmd.ModifyConfig(o.configAccess, *config, true); err != nil {
+		return err
+	}
+
+	for _, message := range messages {
+		fmt.Fprintln(out, message)
+	}
+	return nil
+}
+
+func entryMessage(kind, name string, exists bool) string {
+	if exists {
+		return fmt.Sprintf("%s %q modified.", kind, name)
+	}
+	return fmt.Sprintf("%s %q created.", kind, name)
+}
+
+// modifyCluster merges the inline cluster flags on top of an existing cluster, like 'kubectl cfg add cluster' does.
+func (o *AddContextOptions) modifyCluster(cluster clientcmdapi.Cluster) (clientcmdapi.Cluster, error) {
+	if o.server.Provided() {
+		cluster.Server = o.server.Value()
+	}
+	if o.certificateAuthority.Provided() {
+		path, data, err := fileOrData(o.certificateAuthority.Value(), o.embedCerts)
+		if err != nil {
+			return cluster, err
+		}
+		cluster.CertificateAuthority, cluster.CertificateAuthorityData = path, data
+		// a certificate authority clears insecure mode
+		cluster.InsecureSkipTLSVerify = false
+	}
+	return cluster, nil
+}
+
+// modifyAuthInfo merges the inline user flags on top of an existing user, like 'kubectl cfg add auth' does.
+func (o *AddContextOptions) modifyAuthInfo(authInfo clientcmdapi.AuthInfo) (clientcmdapi.AuthInfo, error) {
+	if o.token.Provided() {
+		authInfo.Token = o.token.Value()
+	}
+	if o.clientCertificate.Provided() {
+		path, data, err := fileOrData(o.clientCertificate.Value(), o.embedCerts)
+		if err != nil {
+			return authInfo, err
+		}
+		authInfo.ClientCertificate, authInfo.ClientCertificateData = path, data
+	}
+	if o.clientKey.Provided() {
+		path, data, err := fileOrData(o.clientKey.Value(), o.embedCerts)
+		if err != nil {
+			return authInfo, err
+		}
+		authInfo.ClientKey, authInfo.ClientKeyData = path, data
+	}
+
+	if o.execCommand.Provided() || o.execAPIVersion.Provided() || len(o.execArgs) != 0 {
+		if authInfo.Exec == nil {
+			authInfo.Exec = &clientcmdapi.ExecConfig{APIVersion: defaultExecAPIVersion}
+		} else {
+			exec := *authInfo.Exec
+			authInfo.Exec = &exec
+		}
+		if o.execCommand.Provided() {
+			authInfo.Exec.Command = o.execCommand.Value()
+		}
+		if o.execAPIVersion.Provided() {
+			authInfo.Exec.APIVersion = o.execAPIVersion.Value()
+		}
+		if len(o.execArgs) != 0 {
+			authInfo.Exec.Args = o.execArgs
+		}
+		if len(authInfo.Exec.Command) == 0 {
+			return authInfo, fmt.Errorf("--%s is required to set up an exec credential plugin", kubectlconfig.FlagExecCommand)
+		}
+	}
+	return authInfo, nil
+}
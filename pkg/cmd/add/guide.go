@@ -0,0 +1,426 @@
+package add
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/it2911/kubectl-cfg/pkg/util/kubeconfig"
+	"github.com/it2911/kubectl-cfg/pkg/util/redact"
+	"golang.org/x/crypto/ssh/terminal"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/tools/clientcmd/api/latest"
+	"k8s.io/kubectl/pkg/util/term"
+)
+
+// the auth methods offered by the guide.
+const (
+	guideToken             = "token"
+	guideClientCertificate = "client-certificate"
+	guideBasic             = "basic"
+	guideExec              = "exec"
+)
+
+// errAborted is returned when the input ends before the guide is done.
+var errAborted = errors.New("aborted, nothing was written")
+
+// isInteractive tells whether the add commands can prompt for their missing arguments.
+func isInteractive(in io.Reader) bool {
+	return term.IsTerminal(in)
+}
+
+// guide walks the user through the creation of kubeconfig entries with prompts, for the add commands
+// run on a terminal without their arguments. The entries are shown and confirmed before a single write.
+type guide struct {
+	configAccess clientcmd.ConfigAccess
+	config       *clientcmdapi.Config
+	// created holds the entries made by the guide, which are shown before they are written.
+	created *clientcmdapi.Config
+
+	in     io.Reader
+	reader *bufio.Reader
+	out    io.Writer
+}
+
+// runGuide starts the guide at the given step, then writes what it created after confirmation.
+func runGuide(streams genericclioptions.IOStreams, configAccess clientcmd.ConfigAccess, step func(g *guide) (string, error)) error {
+	config, err := configAccess.GetStartingConfig()
+	if err != nil {
+		return err
+	}
+	g := &guide{
+		configAccess: configAccess,
+		config:       config,
+		created:      clientcmdapi.NewConfig(),
+		in:           streams.In,
+		reader:       bufio.NewReader(streams.In),
+		out:          streams.Out,
+	}
+
+	if _, err := step(g); err != nil {
+		return err
+	}
+	return g.write()
+}
+
+// ask prompts for a line until it is accepted by validate, an empty answer standing for defaultValue.
+func (g *guide) ask(question, defaultValue string, validate func(string) error) (string, error) {
+	for {
+		if len(defaultValue) != 0 {
+			fmt.Fprintf(g.out, "%s [%s]: ", question, defaultValue)
+		} else {
+			fmt.Fprintf(g.out, "%s: ", question)
+		}
+		line, err := g.reader.ReadString('\n')
+		if err != nil && len(line) == 0 {
+			fmt.Fprintln(g.out)
+			return "", errAborted
+		}
+
+		answer := strings.TrimSpace(line)
+		if len(answer) == 0 {
+			answer = defaultValue
+		}
+		if validate != nil {
+			if err := validate(answer); err != nil {
+				fmt.Fprintf(g.out, "  %v\n", err)
+				continue
+			}
+		}
+		return answer, nil
+	}
+}
+
+// askSecret prompts for a token or a password until it is accepted by validate, without echoing it when the input is a terminal.
+func (g *guide) askSecret(question string, validate func(string) error) (string, error) {
+	f, ok := g.in.(*os.File)
+	if !ok || !terminal.IsTerminal(int(f.Fd())) {
+		return g.ask(question, "", validate)
+	}
+	for {
+		fmt.Fprintf(g.out, "%s: ", question)
+		secret, err := terminal.ReadPassword(int(f.Fd()))
+		fmt.Fprintln(g.out)
+		if err != nil {
+			return "", errAborted
+		}
+		if err := validate(string(secret)); err != nil {
+			fmt.Fprintf(g.out, "  %v\n", err)
+			continue
+		}
+		return string(secret), nil
+	}
+}
+
+// choose prompts for one of the choices, by number or by name.
+func (g *guide) choose(question string, choices []string, defaultValue string) (string, error) {
+	fmt.Fprintf(g.out, "%s:\n", question)
+	for ix, choice := range choices {
+		fmt.Fprintf(g.out, "  %d) %s\n", ix+1, choice)
+	}
+	answer, err := g.ask("Choice", defaultValue, func(answer string) error {
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(choices) {
+			return nil
+		}
+		for _, choice := range choices {
+			if answer == choice {
+				return nil
+			}
+		}
+		return fmt.Errorf("answer a number between 1 and %d", len(choices))
+	})
+	if err != nil {
+		return "", err
+	}
+	if n, err := strconv.Atoi(answer); err == nil {
+		return choices[n-1], nil
+	}
+	return answer, nil
+}
+
+// confirm prompts for a yes or no answer.
+func (g *guide) confirm(question string, defaultYes bool) (bool, error) {
+	hint := "y/N"
+	if defaultYes {
+		hint = "Y/n"
+	}
+	answer, err := g.ask(fmt.Sprintf("%s [%s]", question, hint), "", func(answer string) error {
+		switch strings.ToLower(answer) {
+		case "", "y", "yes", "n", "no":
+			return nil
+		}
+		return errors.New("answer y or n")
+	})
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "":
+		return defaultYes, nil
+	case "y", "yes":
+		return true, nil
+	}
+	return false, nil
+}
+
+// cluster prompts for a new cluster and returns its name.
+func (g *guide) cluster() (string, error) {
+	name, err := g.ask("Cluster name", "", g.newName("cluster", func(name string) bool {
+		_, ok := g.config.Clusters[name]
+		return ok
+	}))
+	if err != nil {
+		return "", err
+	}
+
+	cluster := clientcmdapi.NewCluster()
+	if cluster.Server, err = g.ask("Server URL (https://host:port)", "", validateServer); err != nil {
+		return "", err
+	}
+
+	caFile, err := g.ask("Certificate authority file (empty to use the system roots)", "", optional(validateCertificateFile))
+	if err != nil {
+		return "", err
+	}
+	if len(caFile) != 0 {
+		embed, err := g.confirm("Embed the certificate authority into kubeconfig?", true)
+		if err != nil {
+			return "", err
+		}
+		if cluster.CertificateAuthority, cluster.CertificateAuthorityData, err = fileOrData(caFile, embed); err != nil {
+			return "", err
+		}
+	} else {
+		if cluster.InsecureSkipTLSVerify, err = g.confirm("Skip the verification of the server certificate (insecure)?", false); err != nil {
+			return "", err
+		}
+		if cluster.InsecureSkipTLSVerify {
+			fmt.Fprintf(g.out, "  %s\n", insecureWarning)
+		}
+	}
+
+	g.config.Clusters[name] = cluster
+	g.created.Clusters[name] = cluster
+	return name, nil
+}
+
+// authInfo prompts for a new user and returns its name.
+func (g *guide) authInfo() (string, error) {
+	name, err := g.ask("User name", "", g.newName("user", func(name string) bool {
+		_, ok := g.config.AuthInfos[name]
+		return ok
+	}))
+	if err != nil {
+		return "", err
+	}
+
+	authInfo := clientcmdapi.NewAuthInfo()
+	method, err := g.choose("Authentication method", []string{guideToken, guideClientCertificate, guideBasic, guideExec}, "1")
+	if err != nil {
+		return "", err
+	}
+	switch method {
+	case guideToken:
+		if authInfo.Token, err = g.askSecret("Bearer token", validateToken); err != nil {
+			return "", err
+		}
+		if warning := tokenWarning(authInfo.Token); len(warning) != 0 {
+			fmt.Fprintf(g.out, "  %s\n", warning)
+		}
+	case guideClientCertificate:
+		certFile, err := g.ask("Client certificate file", "", validateCertificateFile)
+		if err != nil {
+			return "", err
+		}
+		keyFile, err := g.ask("Client key file", "", func(keyFile string) error {
+			return validateKeyPair(certFile, keyFile)
+		})
+		if err != nil {
+			return "", err
+		}
+		embed, err := g.confirm("Embed the client certificate and key into kubeconfig?", true)
+		if err != nil {
+			return "", err
+		}
+		if authInfo.ClientCertificate, authInfo.ClientCertificateData, err = fileOrData(certFile, embed); err != nil {
+			return "", err
+		}
+		if authInfo.ClientKey, authInfo.ClientKeyData, err = fileOrData(keyFile, embed); err != nil {
+			return "", err
+		}
+	case guideBasic:
+		if authInfo.Username, err = g.ask("Username", "", required); err != nil {
+			return "", err
+		}
+		if authInfo.Password, err = g.askSecret("Password", required); err != nil {
+			return "", err
+		}
+	case guideExec:
+		command, err := g.ask("Exec plugin command", "", required)
+		if err != nil {
+			return "", err
+		}
+		if _, err := exec.LookPath(command); err != nil {
+			fmt.Fprintf(g.out, "  warning: %s is not found on the PATH\n", command)
+		}
+		args, err := g.ask("Exec plugin arguments, separated by spaces", "", nil)
+		if err != nil {
+			return "", err
+		}
+		apiVersion, err := g.ask("Exec plugin API version", defaultExecAPIVersion, required)
+		if err != nil {
+			return "", err
+		}
+		authInfo.Exec = &clientcmdapi.ExecConfig{Command: command, Args: strings.Fields(args), APIVersion: apiVersion}
+	}
+
+	g.config.AuthInfos[name] = authInfo
+	g.created.AuthInfos[name] = authInfo
+	return name, nil
+}
+
+// context prompts for a new context, its cluster and its user, which can be picked or created, and returns its name.
+func (g *guide) context() (string, error) {
+	name, err := g.ask("Context name", "", g.newName("context", func(name string) bool {
+		_, ok := g.config.Contexts[name]
+		return ok
+	}))
+	if err != nil {
+		return "", err
+	}
+
+	context := clientcmdapi.NewContext()
+	if context.Cluster, err = g.pick("Cluster", "create a new cluster", kubeconfig.ClusterNames(g.config.Clusters), g.cluster); err != nil {
+		return "", err
+	}
+	if context.AuthInfo, err = g.pick("User", "create a new user", kubeconfig.AuthInfoNames(g.config.AuthInfos), g.authInfo); err != nil {
+		return "", err
+	}
+	if context.Namespace, err = g.ask("Namespace (empty for the default one)", "", nil); err != nil {
+		return "", err
+	}
+
+	g.config.Contexts[name] = context
+	g.created.Contexts[name] = context
+
+	use, err := g.confirm(fmt.Sprintf("Switch to the %s context?", name), true)
+	if err != nil {
+		return "", err
+	}
+	if use {
+		g.config.CurrentContext = name
+		g.created.CurrentContext = name
+	}
+	return name, nil
+}
+
+// pick prompts for one of the existing names, or runs create for a new entry.
+func (g *guide) pick(kind, createChoice string, names []string, create func() (string, error)) (string, error) {
+	if len(names) == 0 {
+		return create()
+	}
+	choice, err := g.choose(kind, append([]string{createChoice}, names...), "1")
+	if err != nil {
+		return "", err
+	}
+	if choice == createChoice {
+		return create()
+	}
+	return choice, nil
+}
+
+// newName validates the name of a new entry.
+func (g *guide) newName(kind string, exists func(string) bool) func(string) error {
+	return func(name string) error {
+		if len(name) == 0 {
+			return fmt.Errorf("the %s name is required", kind)
+		}
+		if exists(name) {
+			return fmt.Errorf("%s %q already exists, pass its name to modify it with flags", kind, name)
+		}
+		return nil
+	}
+}
+
+// write shows the created entries with their secrets masked and their certificates shortened, and writes
+// them after confirmation.
+func (g *guide) write() error {
+	preview := g.created.DeepCopy()
+	clientcmdapi.ShortenConfig(preview)
+	redact.Config(preview)
+	converted, err := latest.Scheme.ConvertToVersion(preview, latest.ExternalVersion)
+	if err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(converted)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(g.out, "\n%s\n", data)
+
+	ok, err := g.confirm(fmt.Sprintf("Write these entries to %s?", g.configAccess.GetDefaultFilename()), true)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		fmt.Fprintln(g.out, errAborted.Error())
+		return nil
+	}
+
+	if err := clientcmd.ModifyConfig(g.configAccess, *g.config, true); err != nil {
+		return err
+	}
+	for _, name := range kubeconfig.ClusterNames(g.created.Clusters) {
+		fmt.Fprintf(g.out, "Cluster %q created.\n", name)
+	}
+	for _, name := range kubeconfig.AuthInfoNames(g.created.AuthInfos) {
+		fmt.Fprintf(g.out, "User %q created.\n", name)
+	}
+	for _, name := range kubeconfig.ContextNames(g.created.Contexts) {
+		fmt.Fprintf(g.out, "Context %q created.\n", name)
+	}
+	if len(g.created.CurrentContext) != 0 {
+		fmt.Fprintf(g.out, "Switched to context %q.\n", g.created.CurrentContext)
+	}
+	return nil
+}
+
+func required(answer string) error {
+	if len(answer) == 0 {
+		return errors.New("an answer is required")
+	}
+	return nil
+}
+
+// optional skips validate for an empty answer.
+func optional(validate func(string) error) func(string) error {
+	return func(answer string) error {
+		if len(answer) == 0 {
+			return nil
+		}
+		return validate(answer)
+	}
+}
+
+// fileOrData returns the absolute path of a file, or its content when it is embedded. An empty path clears both.
+func fileOrData(path string, embed bool) (string, []byte, error) {
+	if len(path) == 0 {
+		return "", nil, nil
+	}
+	if embed {
+		data, err := ioutil.ReadFile(path)
+		return "", data, err
+	}
+	path, err := filepath.Abs(path)
+	return path, nil, err
+}
@@ -0,0 +1,119 @@
+package add
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/it2911/kubectl-cfg/pkg/util/cert"
+	"github.com/it2911/kubectl-cfg/pkg/util/kubeconfig"
+)
+
+// insecureWarning is printed whenever a cluster is set to skip the verification of its server certificate.
+const insecureWarning = "warning: the server certificate will not be verified, anyone on the network path can impersonate the cluster and read the credentials sent to it"
+
+// validateServer checks that a server is an absolute https URL.
+func validateServer(server string) error {
+	u, err := url.Parse(server)
+	if err != nil || u.Scheme != "https" || len(u.Host) == 0 {
+		return fmt.Errorf("%q is not an https URL like https://1.2.3.4:6443", server)
+	}
+	return nil
+}
+
+// validateCertificateFile checks that a file holds PEM encoded certificates.
+func validateCertificateFile(file string) error {
+	if len(file) == 0 {
+		return errors.New("a file is required")
+	}
+	if _, err := cert.LoadCertificates(nil, file); err != nil {
+		return fmt.Errorf("error reading the certificates of %s: %v", file, err)
+	}
+	return nil
+}
+
+// validateKeyFile checks that a file holds a PEM encoded private key.
+func validateKeyFile(file string) error {
+	if len(file) == 0 {
+		return errors.New("a file is required")
+	}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || !strings.HasSuffix(block.Type, "PRIVATE KEY") {
+		return fmt.Errorf("%s is not a PEM encoded private key", file)
+	}
+	return nil
+}
+
+// validateKeyPair checks that a client key is the one of the client certificate.
+func validateKeyPair(certFile, keyFile string) error {
+	if err := validateKeyFile(keyFile); err != nil {
+		return err
+	}
+	if _, err := tls.LoadX509KeyPair(certFile, keyFile); err != nil {
+		return fmt.Errorf("the client key %s does not match the client certificate %s: %v", keyFile, certFile, err)
+	}
+	return nil
+}
+
+// validateToken checks that a bearer token is a single word, as it is sent as is in the Authorization header.
+func validateToken(token string) error {
+	switch {
+	case len(token) == 0:
+		return errors.New("the token is empty")
+	case strings.HasPrefix(strings.ToLower(token), "bearer "):
+		return errors.New("the token must be given without its \"Bearer \" prefix")
+	case strings.IndexFunc(token, func(r rune) bool { return unicode.IsSpace(r) || !unicode.IsPrint(r) || r > unicode.MaxASCII }) != -1:
+		return errors.New("the token contains spaces, line breaks or non ASCII characters, was it copied whole?")
+	}
+	return nil
+}
+
+// tokenWarning returns the warning to print about a bearer token, if any.
+func tokenWarning(token string) string {
+	claims, ok := kubeconfig.JWTClaims(token)
+	if !ok || claims.Expiry == 0 {
+		return ""
+	}
+	if expiry := time.Unix(int64(claims.Expiry), 0); expiry.Before(time.Now()) {
+		return fmt.Sprintf("warning: the token expired on %s", cert.FormatDate(expiry))
+	}
+	return ""
+}
+
+// readCertificateAuthorityData decodes --certificate-authority-data, given as base64 or read from in for "-".
+// The data read from in can also be PEM as is.
+func readCertificateAuthorityData(value string, in io.Reader) ([]byte, error) {
+	data := []byte(value)
+	if value == "-" {
+		var err error
+		if data, err = ioutil.ReadAll(in); err != nil {
+			return nil, err
+		}
+	}
+
+	data = bytes.TrimSpace(data)
+	if !bytes.HasPrefix(data, []byte("-----BEGIN")) {
+		decoded, err := base64.StdEncoding.DecodeString(string(bytes.Join(bytes.Fields(data), nil)))
+		if err != nil {
+			return nil, fmt.Errorf("the certificate authority data is neither base64 nor PEM: %v", err)
+		}
+		data = decoded
+	}
+	if _, err := cert.LoadCertificates(data, ""); err != nil {
+		return nil, fmt.Errorf("error reading the certificate authority data: %v", err)
+	}
+	return data, nil
+}
@@ -3,32 +3,46 @@ package delete
 import (
 	"fmt"
 	"io"
+
+	"github.com/it2911/kubectl-cfg/pkg/util/kubeconfig"
+	"github.com/it2911/kubectl-cfg/pkg/util/selector"
+	"github.com/it2911/kubectl-cfg/pkg/util/trash"
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/tools/clientcmd"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/util/templates"
-	"github.com/it2911/kubectl-cfg/pkg/util/yaml"
-
 )
 
 var (
+	deleteAuthinfoLong = templates.LongDesc(fmt.Sprintf(`
+		Delete the specified authinfos from the kubeconfig
+		`+selectionLong, "users which no context references"))
+
 	deleteAuthinfoExample = templates.Examples(`
-		# Delete the minikube cluster
-		kubectl cfg delete cluster minikube`)
+		# Delete the minikube user
+		kubectl cfg delete auth minikube
+
+		# Delete the users of the clusters of the decommissioned eu-west-1 region
+		kubectl cfg delete auth --server-matches=eu-west-1
+
+		# Delete the users which no context references
+		kubectl cfg delete auth --all-orphaned`)
 )
 
 func NewCmdCfgDeleteUser(out, errOut io.Writer, configAccess clientcmd.ConfigAccess) *cobra.Command {
 
 	cmd := &cobra.Command{
-		Use:                   "auth AUTHINFO_NAME",
+		Use:                   "auth [AUTHINFO_NAME_PATTERN...] [--selector=FILTER] [--all-orphaned] [--dry-run]",
 		DisableFlagsInUseLine: true,
-		Short:                 "Delete the specified authinfo from the kubeconfig",
-		Long:                  "Delete the specified authinfo from the kubeconfig",
+		Short:                 "Delete the specified authinfos from the kubeconfig",
+		Long:                  deleteAuthinfoLong,
 		Example:               deleteAuthinfoExample,
 		Run: func(cmd *cobra.Command, args []string) {
 			cmdutil.CheckErr(RunDeleteAuthInfo(out, errOut, configAccess, cmd))
 		},
 	}
+
+	addSelectionFlags(cmd, "users which no context references")
 	return cmd
 }
 
@@ -39,59 +53,25 @@ func RunDeleteAuthInfo(out, errOut io.Writer, configAccess clientcmd.ConfigAcces
 		return err
 	}
 
-	args := cmd.Flags().Args()
-	if len(args) != 1 {
-		cmd.Help()
-		return nil
-	}
-
-	configFile := configAccess.GetDefaultFilename()
-	if configAccess.IsExplicitFile() {
-		configFile = configAccess.GetExplicitFile()
-	}
-
-	name := args[0]
-
-	authInfo, ok := config.AuthInfos[name]
-
-	//backup deleted content to yaml file
-	err = backup(errOut, authInfo, "auth", "user", name)
+	names, ok, err := selectEntries(cmd, config, selector.AuthInfo)
 	if err != nil {
-		fmt.Println("warning: backup to yaml failed.")
-	} else {
-		fmt.Println("info: deleted content backup to .kube/kubectl-cfg-delete-bak.yaml")
+		return err
 	}
-
 	if !ok {
-		return fmt.Errorf("cannot delete auth %s, not in %s", name, configFile)
-	}
-
-	context := config.Contexts[config.CurrentContext]
-
-	if context.AuthInfo == name {
-		fmt.Fprint(errOut, "warning: this removed your active context, use \"kubectl config use-context\" to select another one\n")
-	}
-
-	delete(config.AuthInfos, name)
-
-	if err := clientcmd.ModifyConfig(configAccess, *config, true); err != nil {
-		return err
+		cmd.Help()
+		return nil
 	}
 
-	fmt.Fprintf(out, "deleted authinfo %s from %s\n", name, configFile)
-
-	return nil
-}
+	context := kubeconfig.CurrentContext(config)
 
-
-func backup(errOut io.Writer, i interface{}, op, key, name string) error {
-	t := map[string] interface{}{
-		key+"s" : map[string] interface{}{
-			"name" : name,
-			key : i,
-		},
+	d := newDeletion()
+	for _, name := range names {
+		if context.AuthInfo == name {
+			d.warnings = append(d.warnings, "warning: this removed the user of your active context, use \"kubectl config use-context\" to select another one")
+		}
+		d.add(trash.KindUser, name, config.AuthInfos[name])
+		delete(config.AuthInfos, name)
 	}
 
-	return yaml.WriteYaml(errOut, t, op, name)
+	return d.commit(out, errOut, configAccess, cmd, config)
 }
-
@@ -1,35 +1,48 @@
 package delete
 
 import (
+	"fmt"
 	"io"
 
+	"github.com/it2911/kubectl-cfg/pkg/util/selector"
+	"github.com/it2911/kubectl-cfg/pkg/util/trash"
 	"github.com/spf13/cobra"
 	"k8s.io/client-go/tools/clientcmd"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/util/i18n"
 	"k8s.io/kubectl/pkg/util/templates"
-	"fmt"
 )
 
 var (
+	deleteClusterLong = templates.LongDesc(fmt.Sprintf(`
+		Delete the specified clusters from the kubeconfig
+		`+selectionLong, "clusters which no context references"))
+
 	deleteClusterExample = templates.Examples(`
 		# Delete the minikube cluster
-		kubectl cfg delete cluster minikube`)
+		kubectl cfg delete cluster minikube
+
+		# Delete all the kind clusters
+		kubectl cfg delete cluster "kind-*"
+
+		# Print the clusters which no context references
+		kubectl cfg delete cluster --all-orphaned --dry-run`)
 )
 
 // NewCmdConfigDeleteCluster returns a Command instance for 'config delete-cluster' sub command
 func NewCmdCfgDeleteCluster(out, errOut io.Writer, configAccess clientcmd.ConfigAccess) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:                   "cluster NAME",
+		Use:                   "cluster [NAME_PATTERN...] [--selector=FILTER] [--all-orphaned] [--dry-run]",
 		DisableFlagsInUseLine: true,
-		Short:                 i18n.T("Delete the specified cluster from the kubeconfig"),
-		Long:                  "Delete the specified cluster from the kubeconfig",
+		Short:                 i18n.T("Delete the specified clusters from the kubeconfig"),
+		Long:                  deleteClusterLong,
 		Example:               deleteClusterExample,
 		Run: func(cmd *cobra.Command, args []string) {
 			cmdutil.CheckErr(RunDeleteCluster(out, errOut, configAccess, cmd))
 		},
 	}
 
+	addSelectionFlags(cmd, "clusters which no context references")
 	return cmd
 }
 
@@ -39,39 +52,20 @@ func RunDeleteCluster(out, errOut io.Writer, configAccess clientcmd.ConfigAccess
 		return err
 	}
 
-	args := cmd.Flags().Args()
-	if len(args) != 1 {
-		cmd.Help()
-		return nil
-	}
-
-	configFile := configAccess.GetDefaultFilename()
-	if configAccess.IsExplicitFile() {
-		configFile = configAccess.GetExplicitFile()
-	}
-
-	name := args[0]
-	cluster, ok := config.Clusters[name]
-
-	//backup deleted content to yaml file
-	err = backup(errOut, cluster, "cluster", "cluster", name)
+	names, ok, err := selectEntries(cmd, config, selector.Cluster)
 	if err != nil {
-		fmt.Println("warning: backup to yaml failed.")
-	} else {
-		fmt.Println("info: deleted content backup to .kube/kubectl-cfg-delete-bak.yaml")
+		return err
 	}
-
 	if !ok {
-		return fmt.Errorf("cannot delete cluster %s, not in %s", name, configFile)
+		cmd.Help()
+		return nil
 	}
 
-	delete(config.Clusters, name)
-
-	if err := clientcmd.ModifyConfig(configAccess, *config, true); err != nil {
-		return err
+	d := newDeletion()
+	for _, name := range names {
+		d.add(trash.KindCluster, name, config.Clusters[name])
+		delete(config.Clusters, name)
 	}
 
-	fmt.Fprintf(out, "deleted cluster %s from %s\n", name, configFile)
-
-	return nil
+	return d.commit(out, errOut, configAccess, cmd, config)
 }
@@ -1,35 +1,59 @@
 package delete
 
 import (
+	"fmt"
 	"io"
+	"strings"
 
+	"github.com/it2911/kubectl-cfg/pkg/util/kubeconfig"
+	"github.com/it2911/kubectl-cfg/pkg/util/selector"
+	"github.com/it2911/kubectl-cfg/pkg/util/trash"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/util/i18n"
 	"k8s.io/kubectl/pkg/util/templates"
-	"fmt"
 )
 
 var (
+	deleteContextLong = templates.LongDesc(fmt.Sprintf(`
+		Delete the specified contexts from the kubeconfig
+
+		With --cascade the clusters and the users of the contexts are deleted too, unless another
+		context still uses them.
+		`+selectionLong, "contexts which reference a missing cluster or user"))
+
 	deleteContextExample = templates.Examples(`
 		# Delete the context for the minikube cluster
-		kubectl cfg delete context minikube`)
+		kubectl cfg delete context minikube
+
+		# Delete the context of a torn down EKS cluster, with its cluster and user
+		kubectl cfg delete context eks-staging --cascade
+
+		# Print the contexts of the decommissioned eu-west-1 region which would be deleted
+		kubectl cfg delete context --server-matches=eu-west-1 --cascade --dry-run
+
+		# Delete the contexts whose cluster or user is gone
+		kubectl cfg delete context --all-orphaned`)
 )
 
 // NewCmdConfigDeleteContext returns a Command instance for 'config delete-context' sub command
 func NewCmdCfgDeleteContext(out, errOut io.Writer, configAccess clientcmd.ConfigAccess) *cobra.Command {
 	cmd := &cobra.Command{
-		Use:                   "context NAME",
+		Use:                   "context [NAME_PATTERN...] [--selector=FILTER] [--all-orphaned] [--cascade] [--dry-run]",
 		DisableFlagsInUseLine: true,
-		Short:                 i18n.T("Delete the specified context from the kubeconfig"),
-		Long:                  "Delete the specified context from the kubeconfig",
+		Short:                 i18n.T("Delete the specified contexts from the kubeconfig"),
+		Long:                  deleteContextLong,
 		Example:               deleteContextExample,
 		Run: func(cmd *cobra.Command, args []string) {
 			cmdutil.CheckErr(RunDeleteContext(out, errOut, configAccess, cmd))
 		},
 	}
 
+	cmd.Flags().Bool("cascade", false, "Also delete the clusters and the users of the contexts when no other context uses them")
+	addSelectionFlags(cmd, "contexts which reference a missing cluster or user")
 	return cmd
 }
 
@@ -39,44 +63,67 @@ func RunDeleteContext(out, errOut io.Writer, configAccess clientcmd.ConfigAccess
 		return err
 	}
 
-	args := cmd.Flags().Args()
-	if len(args) != 1 {
+	names, ok, err := selectEntries(cmd, config, selector.Context)
+	if err != nil {
+		return err
+	}
+	if !ok {
 		cmd.Help()
 		return nil
 	}
 
-	configFile := configAccess.GetDefaultFilename()
-	if configAccess.IsExplicitFile() {
-		configFile = configAccess.GetExplicitFile()
+	d := newDeletion()
+	for _, name := range names {
+		if config.CurrentContext == name {
+			d.warnings = append(d.warnings, "warning: this removed your active context, use \"kubectl config use-context\" to select a different one")
+		}
+		d.add(trash.KindContext, name, config.Contexts[name])
 	}
-
-	name := args[0]
-	context, ok := config.Contexts[name]
-
-	//backup deleted content to yaml file
-	err = backup(errOut, context, "context", "context", name)
-	if err != nil {
-		fmt.Println("warning: backup to yaml failed.")
-	} else {
-		fmt.Println("info: deleted content backup to .kube/kubectl-cfg-delete-bak.yaml")
+	deleted := map[string]*clientcmdapi.Context{}
+	for _, name := range names {
+		deleted[name] = config.Contexts[name]
+		delete(config.Contexts, name)
+	}
+	if cmdutil.GetFlagBool(cmd, "cascade") {
+		cascadeDelete(config, deleted, d)
 	}
 
+	return d.commit(out, errOut, configAccess, cmd, config)
+}
 
-	if !ok {
-		return fmt.Errorf("cannot delete context %s, not in %s", name, configFile)
+// cascadeDelete deletes the clusters and the users of the deleted contexts which no remaining context
+// uses, and notes the ones which are kept.
+func cascadeDelete(config *clientcmdapi.Config, contexts map[string]*clientcmdapi.Context, d *deletion) {
+	clusters := sets.NewString()
+	authInfos := sets.NewString()
+	for _, context := range contexts {
+		clusters.Insert(context.Cluster)
+		authInfos.Insert(context.AuthInfo)
 	}
 
-	if config.CurrentContext == name {
-		fmt.Fprint(errOut, "warning: this removed your active context, use \"kubectl config use-context\" to select a different one\n")
+	for _, name := range clusters.List() {
+		cluster, ok := config.Clusters[name]
+		if !ok {
+			continue
+		}
+		if users := kubeconfig.ContextsUsingCluster(config, name); len(users) != 0 {
+			d.notes = append(d.notes, fmt.Sprintf("kept cluster %s, used by context %s", name, strings.Join(users, ", ")))
+			continue
+		}
+		delete(config.Clusters, name)
+		d.add(trash.KindCluster, name, cluster)
 	}
 
-	delete(config.Contexts, name)
-
-	if err := clientcmd.ModifyConfig(configAccess, *config, true); err != nil {
-		return err
+	for _, name := range authInfos.List() {
+		authInfo, ok := config.AuthInfos[name]
+		if !ok {
+			continue
+		}
+		if users := kubeconfig.ContextsUsingAuthInfo(config, name); len(users) != 0 {
+			d.notes = append(d.notes, fmt.Sprintf("kept user %s, used by context %s", name, strings.Join(users, ", ")))
+			continue
+		}
+		delete(config.AuthInfos, name)
+		d.add(trash.KindUser, name, authInfo)
 	}
-
-	fmt.Fprintf(out, "deleted context %s from %s\n", name, configFile)
-
-	return nil
 }
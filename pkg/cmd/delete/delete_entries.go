@@ -0,0 +1,183 @@
+package delete
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/it2911/kubectl-cfg/pkg/util/kubeconfig"
+	"github.com/it2911/kubectl-cfg/pkg/util/selector"
+	"github.com/it2911/kubectl-cfg/pkg/util/trash"
+	"github.com/spf13/cobra"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+)
+
+// selectionLong is the part of the help shared by the delete commands.
+const selectionLong = `
+		Several entries can be deleted at once, by giving several names, glob patterns or /regex/
+		patterns, or with the --selector, --server-matches, --namespace and --user filters of
+		'kubectl cfg list'. --all-orphaned only deletes the %s. Everything is deleted with a single
+		write of the kubeconfig and moved to the trash, from which 'kubectl cfg trash restore' brings
+		it back, and --dry-run only prints what would be deleted.`
+
+// addSelectionFlags binds the flags selecting the entries to delete.
+func addSelectionFlags(cmd *cobra.Command, orphaned string) {
+	filterOptions := &selector.Options{}
+	filterOptions.AddFlags(cmd)
+	cmd.Flags().Bool("all-orphaned", false, "Only delete the "+orphaned)
+	cmd.Flags().Bool("dry-run", false, "Only print the entries which would be deleted")
+}
+
+// selectEntries returns the sorted names of the entries of kind selected by the NAME patterns, the filters
+// and --all-orphaned. ok is false when nothing selects any entry, for the command to print its help.
+func selectEntries(cmd *cobra.Command, config *clientcmdapi.Config, kind selector.Kind) (names []string, ok bool, err error) {
+	args := cmd.Flags().Args()
+	filterOptions := selector.Options{
+		Selector:      cmdutil.GetFlagString(cmd, "selector"),
+		ServerMatches: cmdutil.GetFlagString(cmd, "server-matches"),
+		Namespace:     cmdutil.GetFlagString(cmd, "namespace"),
+		User:          cmdutil.GetFlagString(cmd, "user"),
+	}
+	allOrphaned := cmdutil.GetFlagBool(cmd, "all-orphaned")
+
+	filter, err := filterOptions.ToFilter(kind, args)
+	if err != nil {
+		return nil, true, err
+	}
+	if len(args) == 0 && !filter.HasRequirements() && !allOrphaned {
+		return nil, false, nil
+	}
+
+	// nothing is deleted when a name is wrong, as it is likely a typo
+	selected, errs := filter.Select(config)
+	if len(errs) != 0 {
+		return nil, true, utilerrors.NewAggregate(errs)
+	}
+	if !allOrphaned {
+		return selected, true, nil
+	}
+
+	names = []string{}
+	for _, name := range selected {
+		if isOrphaned(config, kind, name) {
+			names = append(names, name)
+		}
+	}
+	return names, true, nil
+}
+
+// isOrphaned tells whether a context references a missing cluster or user, or whether no context
+// references a cluster or a user, like 'kubectl cfg doctor' does.
+func isOrphaned(config *clientcmdapi.Config, kind selector.Kind, name string) bool {
+	switch kind {
+	case selector.Context:
+		return len(kubeconfig.MissingReferences(config, config.Contexts[name])) != 0
+	case selector.Cluster:
+		return kubeconfig.IsOrphanedCluster(config, name, nil)
+	case selector.AuthInfo:
+		return kubeconfig.IsOrphanedAuthInfo(config, name, nil)
+	}
+	return false
+}
+
+// deletion is what one delete command removes from the kubeconfig.
+type deletion struct {
+	entries  []deletedEntry
+	notes    []string
+	warnings []string
+}
+
+// deletedEntry is an entry removed from the kubeconfig, kind being its kind in the trash.
+type deletedEntry struct {
+	kind  string
+	name  string
+	entry interface{}
+}
+
+func newDeletion() *deletion {
+	return &deletion{}
+}
+
+// add records a deleted entry.
+func (d *deletion) add(kind, name string, entry interface{}) {
+	d.entries = append(d.entries, deletedEntry{kind: kind, name: name, entry: entry})
+}
+
+// file returns the kubeconfig file the entry comes from, configFile when its origin is unknown.
+func (e deletedEntry) file(configFile string) string {
+	origin := ""
+	switch entry := e.entry.(type) {
+	case *clientcmdapi.Context:
+		origin = entry.LocationOfOrigin
+	case *clientcmdapi.Cluster:
+		origin = entry.LocationOfOrigin
+	case *clientcmdapi.AuthInfo:
+		origin = entry.LocationOfOrigin
+	}
+	if len(origin) == 0 {
+		return configFile
+	}
+	return origin
+}
+
+// commit moves the deleted entries to the trash and writes the kubeconfig once, or only prints what would
+// be deleted with --dry-run.
+func (d *deletion) commit(out, errOut io.Writer, configAccess clientcmd.ConfigAccess, cmd *cobra.Command, config *clientcmdapi.Config) error {
+	configFile := configAccess.GetDefaultFilename()
+	if configAccess.IsExplicitFile() {
+		configFile = configAccess.GetExplicitFile()
+	}
+
+	if len(d.entries) == 0 {
+		fmt.Fprintf(out, "nothing to delete from %s\n", configFile)
+		return nil
+	}
+
+	if cmdutil.GetFlagBool(cmd, "dry-run") {
+		for _, e := range d.entries {
+			fmt.Fprintf(out, "would delete %s %s from %s\n", e.kind, e.name, e.file(configFile))
+		}
+		for _, note := range d.notes {
+			fmt.Fprintln(out, note)
+		}
+		return nil
+	}
+
+	// the entries are only deleted once they are safe in the trash
+	if err := d.moveToTrash(configAccess, trash.CommandLine(cmd)); err != nil {
+		return fmt.Errorf("the deleted entries could not be put in the trash, nothing was deleted: %v", err)
+	}
+
+	for _, warning := range d.warnings {
+		fmt.Fprintln(errOut, warning)
+	}
+
+	if err := clientcmd.ModifyConfig(configAccess, *config, true); err != nil {
+		return err
+	}
+
+	for _, e := range d.entries {
+		fmt.Fprintf(out, "deleted %s %s from %s\n", e.kind, e.name, e.file(configFile))
+	}
+	for _, note := range d.notes {
+		fmt.Fprintln(out, note)
+	}
+	fmt.Fprintln(errOut, "info: the deleted entries can be restored with \"kubectl cfg trash restore\"")
+	return nil
+}
+
+// moveToTrash adds the deleted entries to the trash.
+func (d *deletion) moveToTrash(configAccess clientcmd.ConfigAccess, command string) error {
+	store, err := trash.Open(configAccess)
+	if err != nil {
+		return err
+	}
+	for _, e := range d.entries {
+		if _, err := store.Add(e.kind, e.name, e.entry, command); err != nil {
+			return err
+		}
+	}
+	return store.Save()
+}
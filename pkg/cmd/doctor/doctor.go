@@ -0,0 +1,334 @@
+package doctor
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/it2911/kubectl-cfg/pkg/util/kubeconfig"
+	"github.com/it2911/kubectl-cfg/pkg/util/printers"
+	"github.com/it2911/kubectl-cfg/pkg/util/trash"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+const (
+	severityError   = "error"
+	severityWarning = "warning"
+)
+
+var (
+	doctorLong = templates.LongDesc(`
+		Checks the kubeconfig file for broken entries.
+
+		The doctor reports contexts which reference missing clusters or users, a current-context
+		which names a missing context, clusters and users which no context references, certificate,
+		key and token files which don't exist, exec plugin commands which are not found on the PATH
+		and clusters without a server.
+
+		With --fix, the contexts with missing references and the clusters and users which no other
+		context references are pruned, and the current-context is pointed at a remaining context.
+		Pruned entries are moved to the trash like the ones removed by 'kubectl cfg delete'.`)
+
+	doctorExample = templates.Examples(`
+		# Check your kubeconfig file for broken entries
+		kubectl cfg doctor
+
+		# Repair your kubeconfig file after confirmation
+		kubectl cfg doctor --fix
+
+		# Repair your kubeconfig file without confirmation
+		kubectl cfg doctor --fix --yes`)
+)
+
+// DoctorOptions contains the assignable options from the args.
+type DoctorOptions struct {
+	configAccess clientcmd.ConfigAccess
+	fix          bool
+	yes          bool
+	command      string
+
+	genericclioptions.IOStreams
+}
+
+// problem is a single finding of the doctor. fix is nil when the problem can't be repaired automatically.
+type problem struct {
+	severity string
+	kind     string
+	name     string
+	message  string
+	fixNote  string
+	fix      func(config *clientcmdapi.Config) (removedKind string, removed interface{})
+}
+
+// NewCmdCfgDoctor returns a Command instance for 'cfg doctor' command
+func NewCmdCfgDoctor(streams genericclioptions.IOStreams, configAccess clientcmd.ConfigAccess) *cobra.Command {
+	options := &DoctorOptions{
+		configAccess: configAccess,
+		IOStreams:    streams,
+	}
+
+	cmd := &cobra.Command{
+		Use:                   "doctor [--fix] [--yes]",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Detect and repair broken references in the kubeconfig file"),
+		Long:                  doctorLong,
+		Example:               doctorExample,
+		Annotations:           map[string]string{kubeconfig.ReadOnlyUnlessAnnotation: "fix"},
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(options.Complete(cmd))
+			cmdutil.CheckErr(options.RunDoctor())
+		},
+	}
+
+	cmd.Flags().BoolVar(&options.fix, "fix", options.fix, "Prune or re-point the broken entries")
+	cmd.Flags().BoolVarP(&options.yes, "yes", "y", options.yes, "Apply the fixes without asking for confirmation")
+	return cmd
+}
+
+// Complete records the command line, for the entries moved to the trash.
+func (o *DoctorOptions) Complete(cmd *cobra.Command) error {
+	o.command = trash.CommandLine(cmd)
+	return nil
+}
+
+// RunDoctor checks the kubeconfig and repairs it when --fix is given.
+func (o *DoctorOptions) RunDoctor() error {
+	config, err := o.configAccess.GetStartingConfig()
+	if err != nil {
+		return err
+	}
+
+	problems := diagnose(config)
+	if len(problems) == 0 {
+		fmt.Fprintln(o.Out, "No problem found.")
+		return nil
+	}
+
+	if err := printProblems(o.Out, problems); err != nil {
+		return err
+	}
+
+	fixable := []problem{}
+	for _, p := range problems {
+		if p.fix != nil {
+			fixable = append(fixable, p)
+		}
+	}
+
+	if !o.fix {
+		if len(fixable) != 0 {
+			fmt.Fprintf(o.ErrOut, "%d problem(s) can be fixed with \"kubectl cfg doctor --fix\"\n", len(fixable))
+		}
+		return fmt.Errorf("%d problem(s) found", len(problems))
+	}
+
+	if len(fixable) == 0 {
+		return fmt.Errorf("%d problem(s) found, none can be fixed automatically", len(problems))
+	}
+
+	if !o.yes && !confirm(o.In, o.Out, fmt.Sprintf("Apply %d fix(es)? [y/N]: ", len(fixable))) {
+		return fmt.Errorf("aborted, the kubeconfig file was not modified")
+	}
+
+	// the pruned entries are only removed once they are safe in the trash
+	store, err := trash.Open(o.configAccess)
+	if err != nil {
+		return fmt.Errorf("the pruned entries could not be put in the trash, nothing was fixed: %v", err)
+	}
+	fixes := []string{}
+	for _, p := range fixable {
+		removedKind, removed := p.fix(config)
+		if removed != nil {
+			if _, err := store.Add(removedKind, p.name, removed, o.command); err != nil {
+				return fmt.Errorf("%s %s could not be put in the trash, nothing was fixed: %v", removedKind, p.name, err)
+			}
+		}
+		fixes = append(fixes, fmt.Sprintf("%s %s: %s", p.kind, p.name, p.fixNote))
+	}
+	if err := store.Save(); err != nil {
+		return fmt.Errorf("the pruned entries could not be put in the trash, nothing was fixed: %v", err)
+	}
+	for _, fix := range fixes {
+		fmt.Fprintln(o.Out, fix)
+	}
+
+	return clientcmd.ModifyConfig(o.configAccess, *config, true)
+}
+
+// diagnose returns the problems of the kubeconfig, contexts first.
+func diagnose(config *clientcmdapi.Config) []problem {
+	problems := []problem{}
+	pruned := map[string]bool{}
+
+	for _, name := range kubeconfig.ContextNames(config.Contexts) {
+		missing := kubeconfig.MissingReferences(config, config.Contexts[name])
+		if len(missing) == 0 {
+			continue
+		}
+
+		pruned[name] = true
+		contextName := name
+		problems = append(problems, problem{
+			severity: severityError,
+			kind:     "context",
+			name:     name,
+			message:  "references missing " + strings.Join(missing, " and "),
+			fixNote:  "pruned",
+			fix: func(config *clientcmdapi.Config) (string, interface{}) {
+				removed := config.Contexts[contextName]
+				delete(config.Contexts, contextName)
+				return "context", removed
+			},
+		})
+	}
+
+	if p, ok := diagnoseCurrentContext(config, pruned); ok {
+		problems = append(problems, p)
+	}
+
+	for _, name := range kubeconfig.ClusterNames(config.Clusters) {
+		cluster := config.Clusters[name]
+		if len(cluster.Server) == 0 {
+			problems = append(problems, problem{severity: severityError, kind: "cluster", name: name, message: "server is empty"})
+		}
+		if len(cluster.CertificateAuthority) != 0 && !fileExists(cluster.CertificateAuthority) {
+			problems = append(problems, problem{severity: severityError, kind: "cluster", name: name, message: fmt.Sprintf("certificate-authority file %s not found", cluster.CertificateAuthority)})
+		}
+		// the contexts pruned above no longer reference anything, so that a single --fix is enough
+		if kubeconfig.IsOrphanedCluster(config, name, pruned) {
+			problems = append(problems, pruneProblem("cluster", name, kubeconfig.ContextsUsingCluster(config, name), func(config *clientcmdapi.Config) interface{} {
+				removed := config.Clusters[name]
+				delete(config.Clusters, name)
+				return removed
+			}))
+		}
+	}
+
+	for _, name := range kubeconfig.AuthInfoNames(config.AuthInfos) {
+		authInfo := config.AuthInfos[name]
+		files := []struct{ field, path string }{
+			{"client-certificate", authInfo.ClientCertificate},
+			{"client-key", authInfo.ClientKey},
+			{"tokenFile", authInfo.TokenFile},
+		}
+		for _, f := range files {
+			if len(f.path) != 0 && !fileExists(f.path) {
+				problems = append(problems, problem{severity: severityError, kind: "user", name: name, message: fmt.Sprintf("%s file %s not found", f.field, f.path)})
+			}
+		}
+		if command := kubeconfig.ExecCommand(authInfo); len(command) != 0 {
+			if _, err := exec.LookPath(command); err != nil {
+				problems = append(problems, problem{severity: severityError, kind: "user", name: name, message: fmt.Sprintf("exec command %s not found on PATH", command)})
+			}
+		}
+		if kubeconfig.IsOrphanedAuthInfo(config, name, pruned) {
+			problems = append(problems, pruneProblem("user", name, kubeconfig.ContextsUsingAuthInfo(config, name), func(config *clientcmdapi.Config) interface{} {
+				removed := config.AuthInfos[name]
+				delete(config.AuthInfos, name)
+				return removed
+			}))
+		}
+	}
+
+	return problems
+}
+
+// diagnoseCurrentContext reports a current-context which is not set, names a missing context or
+// names a context which is pruned by the fixes.
+func diagnoseCurrentContext(config *clientcmdapi.Config, pruned map[string]bool) (problem, bool) {
+	p := problem{kind: "current-context", name: config.CurrentContext}
+	_, ok := config.Contexts[config.CurrentContext]
+	switch {
+	case len(config.CurrentContext) == 0:
+		p.severity = severityWarning
+		p.name = "<none>"
+		p.message = "current-context is not set"
+	case !ok:
+		p.severity = severityError
+		p.message = "names a missing context"
+	case pruned[config.CurrentContext]:
+		p.severity = severityError
+		p.message = "names a context with missing references"
+	default:
+		return p, false
+	}
+
+	replacement := ""
+	for _, name := range kubeconfig.ContextNames(config.Contexts) {
+		if !pruned[name] {
+			replacement = name
+			break
+		}
+	}
+	if len(replacement) == 0 {
+		if p.severity == severityWarning {
+			return p, true
+		}
+		p.fixNote = "unset"
+	} else {
+		p.fixNote = fmt.Sprintf("set to %q", replacement)
+	}
+	p.fix = func(config *clientcmdapi.Config) (string, interface{}) {
+		config.CurrentContext = replacement
+		return "", nil
+	}
+	return p, true
+}
+
+// pruneProblem reports a cluster or a user which no context references, or only the pruned contexts.
+func pruneProblem(kind, name string, contexts []string, remove func(config *clientcmdapi.Config) interface{}) problem {
+	message := "not referenced by any context"
+	if len(contexts) != 0 {
+		message = "only referenced by the pruned context " + strings.Join(contexts, ", ")
+	}
+	return problem{
+		severity: severityWarning,
+		kind:     kind,
+		name:     name,
+		message:  message,
+		fixNote:  "pruned",
+		fix: func(config *clientcmdapi.Config) (string, interface{}) {
+			return kind, remove(config)
+		},
+	}
+}
+
+func printProblems(out io.Writer, problems []problem) error {
+	w := printers.GetNewTabWriter(out)
+	defer w.Flush()
+
+	if _, err := fmt.Fprintf(w, "%s\n", strings.Join([]string{"SEVERITY", "KIND", "NAME", "PROBLEM", "FIX"}, "\t")); err != nil {
+		return err
+	}
+	for _, p := range problems {
+		fix := p.fixNote
+		if p.fix == nil {
+			fix = "-"
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", p.severity, p.kind, p.name, p.message, fix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func confirm(in io.Reader, out io.Writer, question string) bool {
+	fmt.Fprint(out, question)
+	answer, _ := bufio.NewReader(in).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
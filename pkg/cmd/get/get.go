@@ -1,24 +1,235 @@
 package get
 
 import (
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/it2911/kubectl-cfg/pkg/util/cert"
+	"github.com/it2911/kubectl-cfg/pkg/util/kubeconfig"
+	"github.com/it2911/kubectl-cfg/pkg/util/printers"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	getLong = templates.LongDesc(`
+		Displays the details of one context / cluster / authinfo of the kubeconfig file.
+
+		Every field is shown together with the entries it references, the subjects and expiries of
+		its certificates and tokens, and the contexts which use it.`)
+
+	getExample = templates.Examples(`
+		# Describe an entry of your kubeconfig file
+		kubectl cfg get SUB_COMMAND NAME`)
+)
+
+// validOutputTypes are the output formats accepted by every get sub command, the empty one being the describe view.
+var validOutputTypes = sets.NewString("", "json", "yaml")
+
+const (
+	noneValue     = "<none>"
+	notFoundValue = "<not found>"
+	embeddedValue = "<embedded>"
 )
 
 func NewCmdCfgGet(streams genericclioptions.IOStreams, configAccess clientcmd.ConfigAccess) *cobra.Command {
 
 	cmd := &cobra.Command{
-		Use:                   "get SUBCOMMAND",
+		Use:                   "get SUB_COMMAND",
 		DisableFlagsInUseLine: true,
-		Short:                 "Describe one or many contexts",
-		Long:                  "listContextsLong",
-		Example:               "listContextsExample",
+		Short:                 "Describe one context / cluster / authinfo in detail",
+		Long:                  getLong,
+		Example:               getExample,
 		Run:                   cmdutil.DefaultSubCommandRun(streams.ErrOut),
+		Annotations:           map[string]string{kubeconfig.ReadOnlyAnnotation: "true"},
 	}
 	cmd.AddCommand(NewCmdCfgGetCluster(streams, configAccess))
 	cmd.AddCommand(NewCmdCfgGetContext(streams, configAccess))
 	cmd.AddCommand(NewCmdCfgGetUser(streams, configAccess))
 	return cmd
 }
+
+// description is the detailed view of one kubeconfig entry. It is marshalled as is by the json and
+// yaml outputs.
+type description interface {
+	describe(w *describeWriter, level int)
+	// redact masks the secrets of the description.
+	redact()
+}
+
+// GetOptions contains the assignable options from the args.
+type GetOptions struct {
+	configAccess clientcmd.ConfigAccess
+	name         string
+	outputFormat string
+	showSecrets  bool
+
+	// newDescription builds the description of the named entry, it returns false when there is none.
+	newDescription func(config *clientcmdapi.Config, name string) (description, bool)
+	kind           string
+
+	genericclioptions.IOStreams
+}
+
+// addGetFlags binds the flags shared by the get sub commands.
+func addGetFlags(cmd *cobra.Command, o *GetOptions) {
+	cmd.Flags().StringVarP(&o.outputFormat, "output", "o", o.outputFormat, "Output format. One of: json|yaml")
+	cmd.Flags().BoolVar(&o.showSecrets, "show-secrets", o.showSecrets, "Print the tokens, passwords, auth provider secrets and exec env values instead of masking them")
+}
+
+// Complete assigns GetOptions from the args.
+func (o *GetOptions) Complete(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return cmdutil.UsageErrorf(cmd, "exactly one %s name is required", o.kind)
+	}
+	o.name = args[0]
+
+	if !validOutputTypes.Has(o.outputFormat) {
+		return fmt.Errorf("output must be one of '', 'json' or 'yaml': %v", o.outputFormat)
+	}
+	return nil
+}
+
+// RunGet prints the description of the entry.
+func (o *GetOptions) RunGet() error {
+	config, err := o.configAccess.GetStartingConfig()
+	if err != nil {
+		return err
+	}
+
+	d, ok := o.newDescription(config, o.name)
+	if !ok {
+		return fmt.Errorf("%s %q not found", o.kind, o.name)
+	}
+	if !o.showSecrets {
+		d.redact()
+	}
+
+	switch o.outputFormat {
+	case "json":
+		data, err := json.MarshalIndent(d, "", "    ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(o.Out, "%s\n", data)
+		return err
+	case "yaml":
+		data, err := yaml.Marshal(d)
+		if err != nil {
+			return err
+		}
+		_, err = o.Out.Write(data)
+		return err
+	}
+
+	out := printers.GetNewTabWriter(o.Out)
+	defer out.Flush()
+	d.describe(&describeWriter{out: out}, 0)
+	return nil
+}
+
+// describeWriter writes the "Key:  value" lines of a describe view. Every level is indented by two spaces.
+type describeWriter struct {
+	out io.Writer
+}
+
+// line writes a key and its value, empty values being shown as <none>.
+func (w *describeWriter) line(level int, key string, value interface{}) {
+	text := fmt.Sprint(value)
+	if len(text) == 0 {
+		text = noneValue
+	}
+	fmt.Fprintf(w.out, "%s%s:\t%s\n", strings.Repeat("  ", level), key, text)
+}
+
+// section writes a key which introduces the nested lines that follow.
+func (w *describeWriter) section(level int, key string) {
+	fmt.Fprintf(w.out, "%s%s:\n", strings.Repeat("  ", level), key)
+}
+
+// list writes a key followed by its values joined with commas.
+func (w *describeWriter) list(level int, key string, values []string) {
+	w.line(level, key, strings.Join(values, ", "))
+}
+
+// certificateDescription is the decoded content of a certificate.
+type certificateDescription struct {
+	Subject   string   `json:"subject"`
+	Groups    []string `json:"groups,omitempty"`
+	Issuer    string   `json:"issuer"`
+	NotBefore string   `json:"notBefore"`
+	NotAfter  string   `json:"notAfter"`
+	State     string   `json:"state"`
+}
+
+// describeCertificates decodes the PEM certificates found in data, or in file when data is empty.
+// It returns an error message instead when they can't be read.
+func describeCertificates(data []byte, file string) ([]certificateDescription, string) {
+	certs, err := cert.LoadCertificates(data, file)
+	if err != nil {
+		return nil, err.Error()
+	}
+
+	descriptions := []certificateDescription{}
+	for _, c := range certs {
+		descriptions = append(descriptions, newCertificateDescription(c))
+	}
+	return descriptions, ""
+}
+
+func newCertificateDescription(c *x509.Certificate) certificateDescription {
+	return certificateDescription{
+		Subject:   c.Subject.CommonName,
+		Groups:    c.Subject.Organization,
+		Issuer:    c.Issuer.CommonName,
+		NotBefore: c.NotBefore.UTC().Format(timeFormat),
+		NotAfter:  c.NotAfter.UTC().Format(timeFormat),
+		State:     expiryState(cert.State(c.NotAfter, cert.DefaultExpiryWarning)),
+	}
+}
+
+const timeFormat = "2006-01-02T15:04:05Z"
+
+func expiryState(state cert.ExpiryState) string {
+	switch state {
+	case cert.Expired:
+		return "expired"
+	case cert.Expiring:
+		return "expiring"
+	}
+	return "valid"
+}
+
+func describeCertificateList(w *describeWriter, level int, certs []certificateDescription, certErr string) {
+	if len(certErr) != 0 {
+		w.line(level, "Error", certErr)
+		return
+	}
+	for ix, c := range certs {
+		w.section(level, fmt.Sprintf("Certificate %d", ix+1))
+		w.line(level+1, "Subject", c.Subject)
+		if len(c.Groups) != 0 {
+			w.list(level+1, "Groups", c.Groups)
+		}
+		w.line(level+1, "Issuer", c.Issuer)
+		w.line(level+1, "Not Before", c.NotBefore)
+		w.line(level+1, "Not After", fmt.Sprintf("%s (%s)", c.NotAfter, c.State))
+	}
+}
+
+// fileOrEmbedded tells where a certificate, key or token comes from.
+func fileOrEmbedded(file string, data []byte) string {
+	if len(data) != 0 {
+		return embeddedValue
+	}
+	return file
+}
@@ -1,23 +1,266 @@
 package get
 
 import (
+	"fmt"
+	"os/exec"
+	"sort"
+	"time"
+
+	"github.com/it2911/kubectl-cfg/pkg/util/cert"
+	"github.com/it2911/kubectl-cfg/pkg/util/kubeconfig"
+	"github.com/it2911/kubectl-cfg/pkg/util/redact"
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	getAuthInfoLong = templates.LongDesc(`
+		Displays the details of an authinfo of the kubeconfig file: how it authenticates, the subject,
+		groups and expiry of its credentials, its exec plugin or auth provider, and the contexts and
+		clusters it is used with.`)
+
+	getAuthInfoExample = templates.Examples(`
+		# Describe the minikube authinfo
+		kubectl cfg get auth minikube
+
+		# Describe the minikube authinfo as JSON
+		kubectl cfg get auth minikube -o json`)
 )
 
 func NewCmdCfgGetUser(streams genericclioptions.IOStreams, configAccess clientcmd.ConfigAccess) *cobra.Command {
+	options := &GetOptions{
+		configAccess:   configAccess,
+		kind:           "auth",
+		newDescription: newAuthInfoDescription,
+		IOStreams:      streams,
+	}
 
 	cmd := &cobra.Command{
-		Use:                   "user",
+		Use:                   "auth NAME",
+		Aliases:               []string{"user"},
 		DisableFlagsInUseLine: true,
-		Short:                 "Describe one or many contexts",
-		Long:                  "listContextsLong",
-		Example:               "listContextsExample",
+		Short:                 "Describe an authinfo from the kubeconfig file",
+		Long:                  getAuthInfoLong,
+		Example:               getAuthInfoExample,
 		Run: func(cmd *cobra.Command, args []string) {
-
+			cmdutil.CheckErr(options.Complete(cmd, args))
+			cmdutil.CheckErr(options.RunGet())
 		},
 	}
 
+	addGetFlags(cmd, options)
 	return cmd
 }
+
+// authInfoDescription is the detailed view of an authinfo.
+type authInfoDescription struct {
+	Name       string `json:"name"`
+	Source     string `json:"source,omitempty"`
+	Current    bool   `json:"current"`
+	AuthMethod string `json:"authMethod"`
+
+	Subject         string   `json:"subject,omitempty"`
+	Groups          []string `json:"groups,omitempty"`
+	Expires         string   `json:"expires,omitempty"`
+	ExpiryState     string   `json:"expiryState,omitempty"`
+	CredentialError string   `json:"credentialError,omitempty"`
+
+	Username  string `json:"username,omitempty"`
+	Password  string `json:"password,omitempty"`
+	Token     string `json:"token,omitempty"`
+	TokenFile string `json:"tokenFile,omitempty"`
+
+	ClientCertificate             string                   `json:"clientCertificate,omitempty"`
+	ClientCertificateCertificates []certificateDescription `json:"clientCertificateCertificates,omitempty"`
+	ClientCertificateError        string                   `json:"clientCertificateError,omitempty"`
+	ClientKey                     string                   `json:"clientKey,omitempty"`
+
+	Impersonate       string   `json:"impersonate,omitempty"`
+	ImpersonateGroups []string `json:"impersonateGroups,omitempty"`
+
+	Exec         *execDescription         `json:"exec,omitempty"`
+	AuthProvider *authProviderDescription `json:"authProvider,omitempty"`
+
+	Extensions []string `json:"extensions,omitempty"`
+	Contexts   []string `json:"contexts"`
+	Clusters   []string `json:"clusters"`
+}
+
+// execDescription is the exec credential plugin of an authinfo, with the path its command resolves to.
+type execDescription struct {
+	Command    string            `json:"command"`
+	Path       string            `json:"path,omitempty"`
+	Args       []string          `json:"args,omitempty"`
+	Env        map[string]string `json:"env,omitempty"`
+	APIVersion string            `json:"apiVersion"`
+}
+
+// authProviderDescription is the auth provider of an authinfo.
+type authProviderDescription struct {
+	Name   string            `json:"name"`
+	Config map[string]string `json:"config,omitempty"`
+}
+
+func newAuthInfoDescription(config *clientcmdapi.Config, name string) (description, bool) {
+	authInfo, ok := config.AuthInfos[name]
+	if !ok {
+		return nil, false
+	}
+
+	d := &authInfoDescription{
+		Name:       name,
+		Source:     authInfo.LocationOfOrigin,
+		Current:    kubeconfig.CurrentContext(config).AuthInfo == name,
+		AuthMethod: kubeconfig.AuthMethod(authInfo),
+
+		Username:  authInfo.Username,
+		Password:  authInfo.Password,
+		Token:     authInfo.Token,
+		TokenFile: authInfo.TokenFile,
+
+		ClientCertificate: fileOrEmbedded(authInfo.ClientCertificate, authInfo.ClientCertificateData),
+		ClientKey:         fileOrEmbedded(authInfo.ClientKey, authInfo.ClientKeyData),
+
+		Impersonate:       authInfo.Impersonate,
+		ImpersonateGroups: authInfo.ImpersonateGroups,
+
+		Extensions: kubeconfig.ExtensionNames(authInfo.Extensions),
+		Contexts:   kubeconfig.ContextsUsingAuthInfo(config, name),
+		Clusters:   kubeconfig.ClustersUsedByAuthInfo(config, name),
+	}
+
+	credential, err := kubeconfig.AuthInfoCredential(authInfo)
+	if err != nil {
+		d.CredentialError = err.Error()
+	} else {
+		d.Subject = credential.Subject
+		d.Groups = credential.Groups
+		if !credential.NotAfter.IsZero() {
+			d.Expires = credential.NotAfter.UTC().Format(time.RFC3339)
+			d.ExpiryState = expiryState(cert.State(credential.NotAfter, cert.DefaultExpiryWarning))
+		}
+	}
+
+	if len(authInfo.ClientCertificate) != 0 || len(authInfo.ClientCertificateData) != 0 {
+		d.ClientCertificateCertificates, d.ClientCertificateError = describeCertificates(authInfo.ClientCertificateData, authInfo.ClientCertificate)
+	}
+
+	if authInfo.Exec != nil {
+		d.Exec = &execDescription{
+			Command:    authInfo.Exec.Command,
+			Args:       authInfo.Exec.Args,
+			APIVersion: authInfo.Exec.APIVersion,
+		}
+		if path, err := exec.LookPath(authInfo.Exec.Command); err == nil {
+			d.Exec.Path = path
+		} else {
+			d.Exec.Path = notFoundValue
+		}
+		if len(authInfo.Exec.Env) != 0 {
+			d.Exec.Env = map[string]string{}
+			for _, env := range authInfo.Exec.Env {
+				d.Exec.Env[env.Name] = env.Value
+			}
+		}
+	}
+
+	if authInfo.AuthProvider != nil {
+		d.AuthProvider = &authProviderDescription{
+			Name:   authInfo.AuthProvider.Name,
+			Config: authInfo.AuthProvider.Config,
+		}
+	}
+
+	return d, true
+}
+
+func (d *authInfoDescription) redact() {
+	d.Password = redact.Value(d.Password)
+	d.Token = redact.Value(d.Token)
+	if d.Exec != nil {
+		for name, value := range d.Exec.Env {
+			d.Exec.Env[name] = redact.Value(value)
+		}
+	}
+	if d.AuthProvider != nil {
+		d.AuthProvider.Config = redact.AuthProviderConfig(d.AuthProvider.Config)
+	}
+}
+
+func (d *authInfoDescription) describe(w *describeWriter, level int) {
+	w.line(level, "Name", d.Name)
+	w.line(level, "Source", d.Source)
+	w.line(level, "Current", d.Current)
+	w.line(level, "Auth Type", d.AuthMethod)
+	if len(d.CredentialError) != 0 {
+		w.line(level, "Credential Error", d.CredentialError)
+	}
+	w.line(level, "Subject", d.Subject)
+	w.list(level, "Groups", d.Groups)
+	if len(d.Expires) != 0 {
+		w.line(level, "Expires", fmt.Sprintf("%s (%s)", d.Expires, d.ExpiryState))
+	} else {
+		w.line(level, "Expires", "")
+	}
+
+	if len(d.Username) != 0 || len(d.Password) != 0 {
+		w.line(level, "Username", d.Username)
+		w.line(level, "Password", d.Password)
+	}
+	if len(d.Token) != 0 {
+		w.line(level, "Token", d.Token)
+	}
+	if len(d.TokenFile) != 0 {
+		w.line(level, "Token File", d.TokenFile)
+	}
+	if len(d.ClientCertificate) != 0 {
+		w.line(level, "Client Certificate", d.ClientCertificate)
+		describeCertificateList(w, level+1, d.ClientCertificateCertificates, d.ClientCertificateError)
+	}
+	if len(d.ClientKey) != 0 {
+		w.line(level, "Client Key", d.ClientKey)
+	}
+	if len(d.Impersonate) != 0 {
+		w.line(level, "Impersonate", d.Impersonate)
+		w.list(level, "Impersonate Groups", d.ImpersonateGroups)
+	}
+
+	if d.Exec != nil {
+		w.section(level, "Exec")
+		w.line(level+1, "Command", d.Exec.Command)
+		w.line(level+1, "Path", d.Exec.Path)
+		w.list(level+1, "Args", d.Exec.Args)
+		w.line(level+1, "API Version", d.Exec.APIVersion)
+		describeMap(w, level+1, "Env", d.Exec.Env)
+	}
+	if d.AuthProvider != nil {
+		w.section(level, "Auth Provider")
+		w.line(level+1, "Name", d.AuthProvider.Name)
+		describeMap(w, level+1, "Config", d.AuthProvider.Config)
+	}
+
+	w.list(level, "Extensions", d.Extensions)
+	w.list(level, "Used By Contexts", d.Contexts)
+	w.list(level, "Used With Clusters", d.Clusters)
+}
+
+func describeMap(w *describeWriter, level int, key string, m map[string]string) {
+	if len(m) == 0 {
+		w.line(level, key, "")
+		return
+	}
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	w.section(level, key)
+	for _, k := range keys {
+		w.line(level+1, k, m[k])
+	}
+}
@@ -0,0 +1,82 @@
+package get
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const secretKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: c
+  cluster:
+    server: https://127.0.0.1:6443
+users:
+- name: u
+  user:
+    token: token-secret-1
+    username: admin
+    password: password-secret-2
+    client-key-data: a2V5LXNlY3JldC0z
+    auth-provider:
+      name: oidc
+      config:
+        client-id: visible-client-id
+        client-secret: client-secret-4
+        id-token: id-token-secret-5
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: kubelogin
+      env:
+      - name: LOGIN_PASSWORD
+        value: env-secret-6
+contexts:
+- name: ctx
+  context:
+    cluster: c
+    user: u
+current-context: ctx
+`
+
+func TestGetAuthSecrets(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config")
+	if err := ioutil.WriteFile(file, []byte(secretKubeconfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+	secrets := []string{"token-secret-1", "password-secret-2", "client-secret-4", "id-token-secret-5", "env-secret-6"}
+
+	for _, output := range []string{"yaml", "json", ""} {
+		for _, showSecrets := range []bool{false, true} {
+			pathOptions := clientcmd.NewDefaultPathOptions()
+			pathOptions.LoadingRules.ExplicitPath = file
+			streams, _, out, _ := genericclioptions.NewTestIOStreams()
+			cmd := NewCmdCfgGetUser(streams, pathOptions)
+			args := []string{"u", "-o", output}
+			if showSecrets {
+				args = append(args, "--show-secrets")
+			}
+			cmd.SetArgs(args)
+			if err := cmd.Execute(); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, secret := range secrets {
+				if shown := strings.Contains(out.String(), secret); shown != showSecrets {
+					t.Errorf("-o %q show-secrets=%v: expected %s shown to be %v in:\n%s", output, showSecrets, secret, showSecrets, out)
+				}
+			}
+			// the client key is never printed, only told to be embedded
+			if strings.Contains(out.String(), "a2V5LXNlY3JldC0z") || strings.Contains(out.String(), "key-secret-3") {
+				t.Errorf("-o %q show-secrets=%v: unexpected client key in:\n%s", output, showSecrets, out)
+			}
+			if !strings.Contains(out.String(), "visible-client-id") {
+				t.Errorf("-o %q show-secrets=%v: expected the client-id not to be masked in:\n%s", output, showSecrets, out)
+			}
+		}
+	}
+}
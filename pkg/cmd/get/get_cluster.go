@@ -1,23 +1,100 @@
 package get
 
 import (
+	"github.com/it2911/kubectl-cfg/pkg/util/kubeconfig"
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	getClusterLong = templates.LongDesc(`
+		Displays the details of a cluster of the kubeconfig file: its server, the subjects and
+		expiries of its certificate authority and the contexts which use it.`)
+
+	getClusterExample = templates.Examples(`
+		# Describe the minikube cluster
+		kubectl cfg get cluster minikube
+
+		# Describe the minikube cluster as YAML
+		kubectl cfg get cluster minikube -o yaml`)
 )
 
 func NewCmdCfgGetCluster(streams genericclioptions.IOStreams, configAccess clientcmd.ConfigAccess) *cobra.Command {
+	options := &GetOptions{
+		configAccess:   configAccess,
+		kind:           "cluster",
+		newDescription: newClusterDescription,
+		IOStreams:      streams,
+	}
 
 	cmd := &cobra.Command{
-		Use:                   "cluster",
+		Use:                   "cluster NAME",
 		DisableFlagsInUseLine: true,
-		Short:                 "Describe one or many contexts",
-		Long:                  "listContextsLong",
-		Example:               "listContextsExample",
+		Short:                 "Describe a cluster from the kubeconfig file",
+		Long:                  getClusterLong,
+		Example:               getClusterExample,
 		Run: func(cmd *cobra.Command, args []string) {
-
+			cmdutil.CheckErr(options.Complete(cmd, args))
+			cmdutil.CheckErr(options.RunGet())
 		},
 	}
 
+	addGetFlags(cmd, options)
 	return cmd
 }
+
+// clusterDescription is the detailed view of a cluster.
+type clusterDescription struct {
+	Name                  string `json:"name"`
+	Source                string `json:"source,omitempty"`
+	Current               bool   `json:"current"`
+	Server                string `json:"server"`
+	InsecureSkipTLSVerify bool   `json:"insecureSkipTLSVerify"`
+
+	CertificateAuthority             string                   `json:"certificateAuthority,omitempty"`
+	CertificateAuthorityCertificates []certificateDescription `json:"certificateAuthorityCertificates,omitempty"`
+	CertificateAuthorityError        string                   `json:"certificateAuthorityError,omitempty"`
+
+	Extensions []string `json:"extensions,omitempty"`
+	Contexts   []string `json:"contexts"`
+}
+
+func newClusterDescription(config *clientcmdapi.Config, name string) (description, bool) {
+	cluster, ok := config.Clusters[name]
+	if !ok {
+		return nil, false
+	}
+
+	d := &clusterDescription{
+		Name:                  name,
+		Source:                cluster.LocationOfOrigin,
+		Current:               kubeconfig.CurrentContext(config).Cluster == name,
+		Server:                cluster.Server,
+		InsecureSkipTLSVerify: cluster.InsecureSkipTLSVerify,
+		CertificateAuthority:  fileOrEmbedded(cluster.CertificateAuthority, cluster.CertificateAuthorityData),
+
+		Extensions: kubeconfig.ExtensionNames(cluster.Extensions),
+		Contexts:   kubeconfig.ContextsUsingCluster(config, name),
+	}
+	d.CertificateAuthorityCertificates, d.CertificateAuthorityError = describeCertificates(cluster.CertificateAuthorityData, cluster.CertificateAuthority)
+	return d, true
+}
+
+// redact does nothing, a cluster holds no secret.
+func (d *clusterDescription) redact() {}
+
+func (d *clusterDescription) describe(w *describeWriter, level int) {
+	w.line(level, "Name", d.Name)
+	w.line(level, "Source", d.Source)
+	w.line(level, "Current", d.Current)
+	w.line(level, "Server", d.Server)
+	w.line(level, "Insecure Skip TLS Verify", d.InsecureSkipTLSVerify)
+	w.line(level, "Certificate Authority", d.CertificateAuthority)
+	describeCertificateList(w, level+1, d.CertificateAuthorityCertificates, d.CertificateAuthorityError)
+	w.list(level, "Extensions", d.Extensions)
+	w.list(level, "Used By Contexts", d.Contexts)
+}
@@ -1,33 +1,130 @@
 package get
 
 import (
+	"github.com/it2911/kubectl-cfg/pkg/util/kubeconfig"
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/templates"
 )
 
 var (
-//listContextsLong = templates.LongDesc(`Displays one or many contexts from the kubeconfig file.`)
-//
-//listContextsExample = templates.Examples(`
-//	# List all the contexts in your kubeconfig file
-//	kubectl config get-contexts
-//	# Describe one context in your kubeconfig file.
-//	kubectl config get-contexts my-context`)
+	getContextLong = templates.LongDesc(`
+		Displays the details of a context of the kubeconfig file, together with the details of the
+		cluster and the authinfo it references.`)
+
+	getContextExample = templates.Examples(`
+		# Describe the minikube context
+		kubectl cfg get context minikube
+
+		# Describe the minikube context as YAML
+		kubectl cfg get context minikube -o yaml`)
 )
 
-// NewCmdConfigListContexts creates a command object for the "get-contexts" action, which
-// retrieves one or more contexts from a kubeconfig.
+// NewCmdCfgGetContext creates a command object for the "get context" action, which
+// describes one context of a kubeconfig.
 func NewCmdCfgGetContext(streams genericclioptions.IOStreams, configAccess clientcmd.ConfigAccess) *cobra.Command {
+	options := &GetOptions{
+		configAccess:   configAccess,
+		kind:           "context",
+		newDescription: newContextDescription,
+		IOStreams:      streams,
+	}
 
 	cmd := &cobra.Command{
-		Use:                   "context [(-o|--output=)name)]",
+		Use:                   "context NAME",
 		DisableFlagsInUseLine: true,
-		Short:                 "Describe one or many contexts",
-		Long:                  "listContextsLong",
-		Example:               "listContextsExample",
+		Short:                 "Describe a context from the kubeconfig file",
+		Long:                  getContextLong,
+		Example:               getContextExample,
 		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(options.Complete(cmd, args))
+			cmdutil.CheckErr(options.RunGet())
 		},
 	}
+
+	addGetFlags(cmd, options)
 	return cmd
 }
+
+// contextDescription is the detailed view of a context, with the cluster and the authinfo it references
+// when they exist.
+type contextDescription struct {
+	Name      string `json:"name"`
+	Source    string `json:"source,omitempty"`
+	Current   bool   `json:"current"`
+	Namespace string `json:"namespace,omitempty"`
+
+	Cluster        string              `json:"cluster"`
+	ClusterDetails *clusterDescription `json:"clusterDetails,omitempty"`
+	AuthInfo       string              `json:"user"`
+	// AuthInfoDetails is named after the user field of the kubeconfig.
+	AuthInfoDetails *authInfoDescription `json:"userDetails,omitempty"`
+
+	Extensions []string `json:"extensions,omitempty"`
+}
+
+func newContextDescription(config *clientcmdapi.Config, name string) (description, bool) {
+	context, ok := config.Contexts[name]
+	if !ok {
+		return nil, false
+	}
+
+	d := &contextDescription{
+		Name:      name,
+		Source:    context.LocationOfOrigin,
+		Current:   config.CurrentContext == name,
+		Namespace: context.Namespace,
+		Cluster:   context.Cluster,
+		AuthInfo:  context.AuthInfo,
+
+		Extensions: kubeconfig.ExtensionNames(context.Extensions),
+	}
+	if cluster, ok := newClusterDescription(config, context.Cluster); ok {
+		d.ClusterDetails = cluster.(*clusterDescription)
+	}
+	if authInfo, ok := newAuthInfoDescription(config, context.AuthInfo); ok {
+		d.AuthInfoDetails = authInfo.(*authInfoDescription)
+	}
+	return d, true
+}
+
+func (d *contextDescription) redact() {
+	if d.AuthInfoDetails != nil {
+		d.AuthInfoDetails.redact()
+	}
+}
+
+func (d *contextDescription) describe(w *describeWriter, level int) {
+	w.line(level, "Name", d.Name)
+	w.line(level, "Source", d.Source)
+	w.line(level, "Current", d.Current)
+	w.line(level, "Namespace", d.Namespace)
+
+	if d.ClusterDetails == nil {
+		w.line(level, "Cluster", missingReference(d.Cluster))
+	} else {
+		w.section(level, "Cluster")
+		d.ClusterDetails.describe(w, level+1)
+	}
+
+	if d.AuthInfoDetails == nil {
+		w.line(level, "User", missingReference(d.AuthInfo))
+	} else {
+		w.section(level, "User")
+		d.AuthInfoDetails.describe(w, level+1)
+	}
+
+	w.list(level, "Extensions", d.Extensions)
+}
+
+// missingReference returns the value of a reference without details: empty, for the line to show <none>,
+// when the context has no such reference, or the name followed by <not found> when it names a missing entry.
+func missingReference(name string) string {
+	if len(name) == 0 {
+		return ""
+	}
+	return name + " " + notFoundValue
+}
@@ -0,0 +1,20 @@
+package get
+
+import (
+	"testing"
+)
+
+func TestMissingReference(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "", expected: ""},
+		{name: "gone", expected: "gone <not found>"},
+	}
+	for _, test := range tests {
+		if got := missingReference(test.name); got != test.expected {
+			t.Errorf("%q: expected %q, got %q", test.name, test.expected, got)
+		}
+	}
+}
@@ -1,9 +1,26 @@
 package list
 
 import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/it2911/kubectl-cfg/pkg/util/cert"
+	"github.com/it2911/kubectl-cfg/pkg/util/kubeconfig"
+	"github.com/it2911/kubectl-cfg/pkg/util/printers"
+	"github.com/it2911/kubectl-cfg/pkg/util/selector"
+	"github.com/it2911/kubectl-cfg/pkg/util/settings"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cliprinters "k8s.io/cli-runtime/pkg/printers"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/util/jsonpath"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/util/templates"
 )
@@ -25,13 +42,359 @@ func NewCmdCfgList(streams genericclioptions.IOStreams, configAccess clientcmd.C
 		Long:                  listLong,
 		Example:               listExample,
 		Run:                   cmdutil.DefaultSubCommandRun(streams.ErrOut),
+		Annotations:           map[string]string{kubeconfig.ReadOnlyAnnotation: "true"},
 	}
 
 	cmd.Flags().Bool("no-headers", false, "When using the default or custom-column output format, don't print headers (default print headers).")
 	cmd.Flags().StringP("output", "o", "", "Output format. One of: name")
+	cmd.PersistentFlags().String("color", printers.ColorAuto, "When to color the table output. One of: auto|always|never. auto colors only a terminal and honors NO_COLOR.")
 
 	cmd.AddCommand(NewCmdCfgListContext(streams, configAccess))
 	cmd.AddCommand(NewCmdCfgListCluster(streams, configAccess))
 	cmd.AddCommand(NewCmdCfgListAuthInfo(streams, configAccess))
+	cmd.AddCommand(NewCmdCfgListAll(streams, configAccess))
 	return cmd
 }
+
+// validOutputTypes are the output formats accepted by every list sub command.
+var validOutputTypes = sets.NewString("", "json", "yaml", "wide", "name", "custom-columns", "custom-columns-file", "go-template", "go-template-file", "jsonpath", "jsonpath-file")
+
+// newPrintFlags returns the printers used by the structured output formats of the list sub commands.
+// The table, wide and name formats are printed by the sub commands themselves.
+func newPrintFlags() *genericclioptions.PrintFlags {
+	outputFormat := ""
+	return &genericclioptions.PrintFlags{
+		OutputFormat:         &outputFormat,
+		JSONYamlPrintFlags:   genericclioptions.NewJSONYamlPrintFlags(),
+		TemplatePrinterFlags: genericclioptions.NewKubeTemplatePrintFlags(),
+	}
+}
+
+// addOutputFlags binds the --output, --no-headers and template flags of a list sub command.
+func addOutputFlags(cmd *cobra.Command, printFlags *genericclioptions.PrintFlags) {
+	printFlags.TemplatePrinterFlags.AddFlags(cmd)
+	cmd.Flags().Bool("no-headers", false, "When using the default or custom-column output format, don't print headers (default print headers).")
+	cmd.Flags().StringVarP(printFlags.OutputFormat, "output", "o", *printFlags.OutputFormat, "Output format. One of: "+strings.Join(validOutputTypes.List()[1:], "|"))
+	printFlags.OutputFlagSpecified = func() bool {
+		return cmd.Flag("output").Changed
+	}
+}
+
+// validateOutput checks the --output flag of a list sub command.
+func validateOutput(cmd *cobra.Command) error {
+	outputFormat := strings.SplitN(cmdutil.GetFlagString(cmd, "output"), "=", 2)[0]
+	if !validOutputTypes.Has(outputFormat) {
+		return fmt.Errorf("output must be one of %s: %v", strings.Join(validOutputTypes.List()[1:], ", "), outputFormat)
+	}
+	return nil
+}
+
+// isStructuredOutput tells whether the output format is printed from the structured items
+// instead of the table.
+func isStructuredOutput(outputFormat string) bool {
+	switch outputFormat {
+	case "", "name", "wide":
+		return false
+	}
+	return true
+}
+
+// toPrinter returns the printer matching the --output flag of a list sub command.
+func toPrinter(printFlags *genericclioptions.PrintFlags, noHeaders bool) (cliprinters.ResourcePrinter, error) {
+	parts := strings.SplitN(*printFlags.OutputFormat, "=", 2)
+	switch parts[0] {
+	case "custom-columns":
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("custom-columns format specified but no custom columns given")
+		}
+		return printers.NewCustomColumnsPrinterFromSpec(parts[1], noHeaders)
+	case "custom-columns-file":
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("custom-columns-file format specified but no file given")
+		}
+		return printers.NewCustomColumnsPrinterFromFile(parts[1], noHeaders)
+	}
+	return printFlags.ToPrinter()
+}
+
+// printItems prints the structured items of a list sub command as a single List object.
+func printItems(printer cliprinters.ResourcePrinter, items []interface{}, out io.Writer) error {
+	list := make([]interface{}, 0, len(items))
+	for _, item := range items {
+		content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(item)
+		if err != nil {
+			return err
+		}
+		list = append(list, content)
+	}
+
+	return printer.PrintObj(&unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "List",
+		"items":      list,
+	}}, out)
+}
+
+// tableStyle paints the cells of the list tables according to the --color flag and the theme of the
+// settings file.
+type tableStyle struct {
+	colors     *printers.Colorizer
+	production []selector.Pattern
+}
+
+// newTableStyle loads the settings file. The --color flag falls back to the color of the settings when
+// it is not given.
+func newTableStyle(cmd *cobra.Command, out io.Writer) (*tableStyle, error) {
+	s, err := settings.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	mode := s.Color
+	if flag := cmd.Flag("color"); flag != nil && (flag.Changed || len(mode) == 0) {
+		mode = flag.Value.String()
+	}
+	colors, err := printers.NewColorizer(mode, out, s.Theme)
+	if err != nil {
+		return nil, err
+	}
+
+	style := &tableStyle{colors: colors}
+	for _, p := range s.Production {
+		pattern, err := selector.ParsePattern(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid production pattern of the settings file: %v", err)
+		}
+		style.production = append(style.production, pattern)
+	}
+	return style, nil
+}
+
+// isProduction tells whether one of the names matches a production pattern of the settings file.
+func (s *tableStyle) isProduction(names ...string) bool {
+	for _, pattern := range s.production {
+		for _, name := range names {
+			if pattern.Match(name) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// printRow writes a table row prefixed by the CURRENT column. Every plain cell of a production entry
+// or of the current entry is highlighted, production taking precedence; cells which are already
+// colored keep their own color.
+func printRow(w io.Writer, style *tableStyle, current, production bool, cells ...interface{}) error {
+	prefix := " "
+	if current {
+		prefix = "*"
+	}
+
+	columns := []string{fmt.Sprint(style.highlight(prefix, current, production))}
+	for _, cell := range cells {
+		columns = append(columns, fmt.Sprint(style.highlight(cell, current, production)))
+	}
+	_, err := fmt.Fprintf(w, "%s\n", strings.Join(columns, "\t"))
+	return err
+}
+
+func (s *tableStyle) highlight(cell interface{}, current, production bool) interface{} {
+	if text, ok := cell.(string); !ok || len(text) == 0 {
+		return cell
+	}
+	switch {
+	case production:
+		return s.colors.Production(cell)
+	case current:
+		return s.colors.Current(cell)
+	}
+	return cell
+}
+
+// colorExpiry colors the text of an expiry date with the expired color when it is expired and with
+// the expiring color when it expires within the warning window.
+func (s *tableStyle) colorExpiry(text string, notAfter time.Time, warning time.Duration) interface{} {
+	switch cert.State(notAfter, warning) {
+	case cert.Expired:
+		return s.colors.Expired(text)
+	case cert.Expiring:
+		return s.colors.Expiring(text)
+	}
+	return text
+}
+
+// sourceIndex tells which files of the loading precedence define the entries of the merged config.
+type sourceIndex struct {
+	files []kubeconfig.FileConfig
+}
+
+// shadowedEntry is the definition of an entry by a file which loses the merge to an earlier file.
+// Its config is the merged config in which the entry is replaced by that definition.
+type shadowedEntry struct {
+	file   string
+	config *clientcmdapi.Config
+}
+
+// loadSources returns the sourceIndex of the loading precedence when the SOURCE column is shown, which
+// is always the case with --show-source, and with the wide and structured outputs when KUBECONFIG
+// spans several files. It returns nil otherwise.
+func loadSources(configAccess clientcmd.ConfigAccess, showSource bool, outputFormat string) (*sourceIndex, error) {
+	// the files are parsed again only when their column may be shown
+	if !showSource && (configAccess.IsExplicitFile() || len(configAccess.GetLoadingPrecedence()) < 2 ||
+		outputFormat != "wide" && !isStructuredOutput(outputFormat)) {
+		return nil, nil
+	}
+	files, err := kubeconfig.LoadFiles(configAccess)
+	if err != nil {
+		return nil, err
+	}
+	if showSource || len(files) > 1 && (outputFormat == "wide" || isStructuredOutput(outputFormat)) {
+		return &sourceIndex{files: files}, nil
+	}
+	return nil, nil
+}
+
+// sources returns the files defining the entry in precedence order. The first one wins the merge.
+func (s *sourceIndex) sources(kind selector.Kind, name string) []string {
+	if s == nil {
+		return nil
+	}
+	files := []string{}
+	for _, f := range s.files {
+		if hasEntry(f.Config, kind, name) {
+			files = append(files, f.File)
+		}
+	}
+	return files
+}
+
+// shadowed returns the definitions of the entry by the files which lose the merge.
+func (s *sourceIndex) shadowed(config *clientcmdapi.Config, kind selector.Kind, name string) []shadowedEntry {
+	if s == nil {
+		return nil
+	}
+	entries := []shadowedEntry{}
+	found := false
+	for _, f := range s.files {
+		if !hasEntry(f.Config, kind, name) {
+			continue
+		}
+		if !found {
+			found = true
+			continue
+		}
+		shadowed := config.DeepCopy()
+		switch kind {
+		case selector.Context:
+			shadowed.Contexts[name] = f.Config.Contexts[name]
+		case selector.Cluster:
+			shadowed.Clusters[name] = f.Config.Clusters[name]
+		case selector.AuthInfo:
+			shadowed.AuthInfos[name] = f.Config.AuthInfos[name]
+		}
+		entries = append(entries, shadowedEntry{file: f.File, config: shadowed})
+	}
+	return entries
+}
+
+// sourceCells returns the SOURCE cell of the entry which wins the merge, or no cell when the column is hidden.
+func (s *sourceIndex) sourceCells(kind selector.Kind, name string) []interface{} {
+	if s == nil {
+		return nil
+	}
+	source := ""
+	if sources := s.sources(kind, name); len(sources) != 0 {
+		source = sources[0]
+	}
+	return []interface{}{source}
+}
+
+func hasEntry(config *clientcmdapi.Config, kind selector.Kind, name string) bool {
+	ok := false
+	switch kind {
+	case selector.Context:
+		_, ok = config.Contexts[name]
+	case selector.Cluster:
+		_, ok = config.Clusters[name]
+	case selector.AuthInfo:
+		_, ok = config.AuthInfos[name]
+	}
+	return ok
+}
+
+// addSortFlags binds the --sort-by and --reverse flags of a list sub command.
+func addSortFlags(cmd *cobra.Command, sortBy *string, reverse *bool, columns map[string]string) {
+	names := []string{}
+	for name := range columns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	cmd.Flags().StringVar(sortBy, "sort-by", *sortBy, "Sort the list by one of the columns ("+strings.Join(names, ", ")+") or by a JSONPath expression of the json output, e.g. '{.server}'.")
+	cmd.Flags().BoolVar(reverse, "reverse", *reverse, "Reverse the order of the list")
+}
+
+// sortNames orders the names and their items by the value found in every item at sortBy,
+// which is either a key of the columns map or a JSONPath expression. Items without a value
+// are placed last.
+func sortNames(names []string, items []interface{}, sortBy string, columns map[string]string, reverse bool) ([]string, []interface{}, error) {
+	indices := make([]int, len(names))
+	for ix := range indices {
+		indices[ix] = ix
+	}
+
+	if len(sortBy) != 0 {
+		fieldSpec, ok := columns[sortBy]
+		if !ok {
+			var err error
+			if fieldSpec, err = printers.RelaxedJSONPathExpression(sortBy); err != nil {
+				return nil, nil, fmt.Errorf("--sort-by must be one of the columns or a JSONPath expression: %v", err)
+			}
+		}
+		parser := jsonpath.New("sorting").AllowMissingKeys(true)
+		if err := parser.Parse(fieldSpec); err != nil {
+			return nil, nil, err
+		}
+
+		keys := make([]string, len(items))
+		for ix, item := range items {
+			content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(item)
+			if err != nil {
+				return nil, nil, err
+			}
+			results, err := parser.FindResults(content)
+			if err != nil {
+				return nil, nil, err
+			}
+			values := []string{}
+			for _, result := range results {
+				for _, value := range result {
+					values = append(values, fmt.Sprint(value.Interface()))
+				}
+			}
+			keys[ix] = strings.Join(values, ",")
+		}
+
+		sort.SliceStable(indices, func(i, j int) bool {
+			a, b := keys[indices[i]], keys[indices[j]]
+			if (len(a) == 0) != (len(b) == 0) {
+				return len(b) == 0
+			}
+			return a < b
+		})
+	}
+
+	if reverse {
+		for i, j := 0, len(indices)-1; i < j; i, j = i+1, j-1 {
+			indices[i], indices[j] = indices[j], indices[i]
+		}
+	}
+
+	sortedNames := make([]string, len(names))
+	sortedItems := make([]interface{}, len(items))
+	for ix, index := range indices {
+		sortedNames[ix] = names[index]
+		sortedItems[ix] = items[index]
+	}
+	return sortedNames, sortedItems, nil
+}
@@ -0,0 +1,224 @@
+package list
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/it2911/kubectl-cfg/pkg/util/kubeconfig"
+	"github.com/it2911/kubectl-cfg/pkg/util/printers"
+	"github.com/juju/ansiterm"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	listAllLong = templates.LongDesc(`
+		Displays the whole kubeconfig file as a tree.
+
+		Every context is shown with the cluster and the user it references, followed by the
+		clusters and the users which are not referenced by any context.`)
+
+	listAllExample = templates.Examples(`
+		# Show the tree of contexts, clusters and users in your kubeconfig file
+		kubectl cfg list all
+
+		# List the names of every entry in your kubeconfig file
+		kubectl cfg list all -o name`)
+)
+
+const (
+	treeBranch = "├── "
+	treeLast   = "└── "
+)
+
+// ListAllOptions contains the assignable options from the args.
+type ListAllOptions struct {
+	configAccess clientcmd.ConfigAccess
+	nameOnly     bool
+	showHeaders  bool
+	style        *tableStyle
+
+	genericclioptions.IOStreams
+}
+
+// NewCmdCfgListAll creates a command object for the "list all" action, which
+// renders the contexts, clusters and users of a kubeconfig as a tree.
+func NewCmdCfgListAll(streams genericclioptions.IOStreams, configAccess clientcmd.ConfigAccess) *cobra.Command {
+	options := &ListAllOptions{
+		configAccess: configAccess,
+		IOStreams:    streams,
+	}
+
+	cmd := &cobra.Command{
+		Use:                   "all",
+		Aliases:               []string{"tree"},
+		DisableFlagsInUseLine: true,
+		Short:                 "Describe the contexts / clusters / authinfos of the kubeconfig file as a tree",
+		Long:                  listAllLong,
+		Example:               listAllExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			outputFormat := cmdutil.GetFlagString(cmd, "output")
+			if !sets.NewString("", "name").Has(outputFormat) {
+				cmdutil.CheckErr(fmt.Errorf("output must be one of '' or 'name': %v", outputFormat))
+			}
+			cmdutil.CheckErr(options.Complete(cmd, args))
+			cmdutil.CheckErr(options.RunList())
+		},
+	}
+
+	cmd.Flags().Bool("no-headers", false, "When using the default output format, don't print headers (default print headers).")
+	cmd.Flags().StringP("output", "o", "", "Output format. One of: name")
+	return cmd
+}
+
+// Complete assigns ListAllOptions from the args.
+func (o *ListAllOptions) Complete(cmd *cobra.Command, args []string) error {
+	o.nameOnly = cmdutil.GetFlagString(cmd, "output") == "name"
+	o.showHeaders = !cmdutil.GetFlagBool(cmd, "no-headers") && !o.nameOnly
+
+	style, err := newTableStyle(cmd, o.Out)
+	if err != nil {
+		return err
+	}
+	o.style = style
+	return nil
+}
+
+// RunList implements all the necessary functionality for the tree view.
+func (o *ListAllOptions) RunList() error {
+	config, err := o.configAccess.GetStartingConfig()
+	if err != nil {
+		return err
+	}
+
+	contextNames := kubeconfig.ContextNames(config.Contexts)
+	orphanedClusters := []string{}
+	for _, name := range kubeconfig.ClusterNames(config.Clusters) {
+		if len(kubeconfig.ContextsUsingCluster(config, name)) == 0 {
+			orphanedClusters = append(orphanedClusters, name)
+		}
+	}
+	orphanedAuthInfos := []string{}
+	for _, name := range kubeconfig.AuthInfoNames(config.AuthInfos) {
+		if len(kubeconfig.ContextsUsingAuthInfo(config, name)) == 0 {
+			orphanedAuthInfos = append(orphanedAuthInfos, name)
+		}
+	}
+
+	if o.nameOnly {
+		return printAllNames(o.Out, contextNames, kubeconfig.ClusterNames(config.Clusters), kubeconfig.AuthInfoNames(config.AuthInfos))
+	}
+
+	out, found := o.Out.(*ansiterm.TabWriter)
+	if !found {
+		out = printers.GetNewTabWriter(o.Out)
+		defer out.Flush()
+	}
+
+	if o.showHeaders {
+		if _, err := fmt.Fprintf(out, "%s\n", strings.Join([]string{"CURRENT", "NAME", "DETAIL"}, "\t")); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range contextNames {
+		if err := printContextTree(out, config, o.style, name, config.CurrentContext == name); err != nil {
+			return err
+		}
+	}
+
+	if len(orphanedClusters) != 0 {
+		details := make([]string, len(orphanedClusters))
+		for ix, name := range orphanedClusters {
+			details[ix] = config.Clusters[name].Server
+		}
+		if err := printOrphanTree(out, o.style, "(unreferenced clusters)", orphanedClusters, details); err != nil {
+			return err
+		}
+	}
+
+	if len(orphanedAuthInfos) != 0 {
+		details := make([]string, len(orphanedAuthInfos))
+		for ix, name := range orphanedAuthInfos {
+			details[ix] = kubeconfig.AuthMethod(config.AuthInfos[name])
+		}
+		if err := printOrphanTree(out, o.style, "(unreferenced users)", orphanedAuthInfos, details); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func printContextTree(w io.Writer, config *clientcmdapi.Config, style *tableStyle, name string, current bool) error {
+	context := config.Contexts[name]
+	production := style.isProduction(name, context.Cluster)
+	namespace := ""
+	if len(context.Namespace) != 0 {
+		namespace = "namespace: " + context.Namespace
+	}
+	if err := printRow(w, style, current, production, name, namespace); err != nil {
+		return err
+	}
+
+	server := "<not found>"
+	if cluster, ok := config.Clusters[context.Cluster]; ok {
+		server = cluster.Server
+	}
+	if err := printTreeLeaf(w, style, current, production, treeBranch+"cluster: "+context.Cluster, server); err != nil {
+		return err
+	}
+
+	authMethod := "<not found>"
+	if authInfo, ok := config.AuthInfos[context.AuthInfo]; ok {
+		authMethod = kubeconfig.AuthMethod(authInfo)
+	}
+	return printTreeLeaf(w, style, current, production, treeLast+"user: "+context.AuthInfo, authMethod)
+}
+
+func printOrphanTree(w io.Writer, style *tableStyle, title string, names, details []string) error {
+	if err := printRow(w, style, false, false, title, ""); err != nil {
+		return err
+	}
+	for ix, name := range names {
+		branch := treeBranch
+		if ix == len(names)-1 {
+			branch = treeLast
+		}
+		if err := printTreeLeaf(w, style, false, false, branch+name, details[ix]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printTreeLeaf writes a row below a tree node, leaving the CURRENT column empty.
+func printTreeLeaf(w io.Writer, style *tableStyle, current, production bool, name, detail string) error {
+	_, err := fmt.Fprintf(w, " \t%s\t%s\n", style.highlight(name, current, production), style.highlight(detail, current, production))
+	return err
+}
+
+func printAllNames(w io.Writer, contexts, clusters, authInfos []string) error {
+	for _, name := range contexts {
+		if _, err := fmt.Fprintf(w, "context/%s\n", name); err != nil {
+			return err
+		}
+	}
+	for _, name := range clusters {
+		if _, err := fmt.Fprintf(w, "cluster/%s\n", name); err != nil {
+			return err
+		}
+	}
+	for _, name := range authInfos {
+		if _, err := fmt.Fprintf(w, "user/%s\n", name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -3,16 +3,18 @@ package list
 import (
 	"fmt"
 	"io"
-	"sort"
 	"strings"
+	"time"
 
+	"github.com/it2911/kubectl-cfg/pkg/util/cert"
+	"github.com/it2911/kubectl-cfg/pkg/util/kubeconfig"
 	"github.com/it2911/kubectl-cfg/pkg/util/printers"
+	"github.com/it2911/kubectl-cfg/pkg/util/selector"
 	"github.com/juju/ansiterm"
-	. "github.com/logrusorgru/aurora"
 	"github.com/spf13/cobra"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
-	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cliprinters "k8s.io/cli-runtime/pkg/printers"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
@@ -24,15 +26,41 @@ var (
 
 	listAuthInfoExample = templates.Examples(`
 		# List all the auth info in your kubeconfig file
-		kubectl cfg list auth`)
+		kubectl cfg list auth
+
+		# Highlight the credentials which expire within the next 7 days
+		kubectl cfg list auth --expiry-warning=168h
+
+		# List the auth info with the contexts and clusters they are used with
+		kubectl cfg list auth -o wide`)
 )
 
+// authInfoSortColumns are the columns list auth can be sorted by.
+var authInfoSortColumns = map[string]string{
+	"name":    "{.name}",
+	"user":    "{.name}",
+	"type":    "{.authMethod}",
+	"subject": "{.subject}",
+	"expiry":  "{.expires}",
+}
+
 // ListAuthInfoOptions contains the assignable options from the args.
 type ListAuthInfoOptions struct {
-	configAccess clientcmd.ConfigAccess
-	nameOnly     bool
-	showHeaders  bool
-	authInfos    []string
+	configAccess  clientcmd.ConfigAccess
+	nameOnly      bool
+	wide          bool
+	showHeaders   bool
+	printFlags    *genericclioptions.PrintFlags
+	printer       cliprinters.ResourcePrinter
+	filterOptions selector.Options
+	filter        *selector.Filter
+	sortBy        string
+	reverse       bool
+	style         *tableStyle
+	showSource    bool
+	sources       *sourceIndex
+
+	expiryWarning time.Duration
 
 	genericclioptions.IOStreams
 }
@@ -40,48 +68,69 @@ type ListAuthInfoOptions struct {
 func NewCmdCfgListAuthInfo(streams genericclioptions.IOStreams, configAccess clientcmd.ConfigAccess) *cobra.Command {
 	options := &ListAuthInfoOptions{
 		configAccess: configAccess,
+		printFlags:   newPrintFlags(),
 		IOStreams:    streams,
 	}
 
 	cmd := &cobra.Command{
-		Use:                   "auth",
+		Use:                   "auth [NAME_PATTERN...]",
 		DisableFlagsInUseLine: true,
 		Short:                 "Describe  auth info from the kubeconfig file",
 		Long:                  listAuthInfoLong,
 		Example:               listAuthInfoExample,
 		Run: func(cmd *cobra.Command, args []string) {
-			validOutputTypes := sets.NewString("", "json", "yaml", "wide", "name", "custom-columns", "custom-columns-file", "go-template", "go-template-file", "jsonpath", "jsonpath-file")
-			supportedOutputTypes := sets.NewString("", "name")
-			outputFormat := cmdutil.GetFlagString(cmd, "output")
-			if !validOutputTypes.Has(outputFormat) {
-				cmdutil.CheckErr(fmt.Errorf("output must be one of '' or 'name': %v", outputFormat))
-			}
-			if !supportedOutputTypes.Has(outputFormat) {
-				fmt.Fprintf(options.Out, "--output %v is not available in kubectl config get-contexts; resetting to default output format\n", outputFormat)
-				cmd.Flags().Set("output", "")
-			}
+			cmdutil.CheckErr(validateOutput(cmd))
 			cmdutil.CheckErr(options.Complete(cmd, args))
 			cmdutil.CheckErr(options.RunList())
 		},
 	}
 
-	cmd.Flags().Bool("no-headers", false, "When using the default or custom-column output format, don't print headers (default print headers).")
-	cmd.Flags().StringP("output", "o", "", "Output format. One of: name")
+	addOutputFlags(cmd, options.printFlags)
+	options.filterOptions.AddFlags(cmd)
+	addSortFlags(cmd, &options.sortBy, &options.reverse, authInfoSortColumns)
+	cmd.Flags().BoolVar(&options.showSource, "show-source", options.showSource, "Show the kubeconfig file defining every entry, and the entries shadowed by an earlier file of KUBECONFIG")
+	cmd.Flags().DurationVar(&options.expiryWarning, "expiry-warning", cert.DefaultExpiryWarning, "Highlight the credentials which expire within this duration")
 	return cmd
 }
 
 // Complete assigns ListClustersOptions from the args.
 func (o *ListAuthInfoOptions) Complete(cmd *cobra.Command, args []string) error {
-	o.authInfos = args
 	o.nameOnly = false
 	if cmdutil.GetFlagString(cmd, "output") == "name" {
 		o.nameOnly = true
 	}
+	o.wide = cmdutil.GetFlagString(cmd, "output") == "wide"
 	o.showHeaders = true
 	if cmdutil.GetFlagBool(cmd, "no-headers") || o.nameOnly {
 		o.showHeaders = false
 	}
 
+	filter, err := o.filterOptions.ToFilter(selector.AuthInfo, args)
+	if err != nil {
+		return err
+	}
+	o.filter = filter
+
+	style, err := newTableStyle(cmd, o.Out)
+	if err != nil {
+		return err
+	}
+	o.style = style
+
+	sources, err := loadSources(o.configAccess, o.showSource, *o.printFlags.OutputFormat)
+	if err != nil {
+		return err
+	}
+	o.sources = sources
+
+	if isStructuredOutput(*o.printFlags.OutputFormat) {
+		printer, err := toPrinter(o.printFlags, cmdutil.GetFlagBool(cmd, "no-headers"))
+		if err != nil {
+			return err
+		}
+		o.printer = printer
+	}
+
 	return nil
 }
 
@@ -93,51 +142,62 @@ func (o *ListAuthInfoOptions) RunList() error {
 		return err
 	}
 
+	// Build a list of names to print, and warn if any requested names are not found.
+	// Do this before printing the headers so it doesn't look ugly.
+	toPrint, allErrs := o.filter.Select(config)
+	toPrint, items, err := sortNames(toPrint, o.toItems(config, toPrint), o.sortBy, authInfoSortColumns, o.reverse)
+	if err != nil {
+		return err
+	}
+
+	if o.printer != nil {
+		if err := printItems(o.printer, items, o.Out); err != nil {
+			allErrs = append(allErrs, err)
+		}
+		return utilerrors.NewAggregate(allErrs)
+	}
+
 	out, found := o.Out.(*ansiterm.TabWriter)
 	if !found {
 		out = printers.GetNewTabWriter(o.Out)
 		defer out.Flush()
 	}
 
-	// Build a list of context names to print, and warn if any requested contexts are not found.
-	// Do this before printing the headers so it doesn't look ugly.
-	allErrs := []error{}
-	toPrint := []string{}
-	if len(o.authInfos) == 0 {
-		for name := range config.AuthInfos {
-			toPrint = append(toPrint, name)
-		}
-	} else {
-		for _, name := range o.authInfos {
-			_, ok := config.AuthInfos[name]
-			if ok {
-				toPrint = append(toPrint, name)
-			} else {
-				allErrs = append(allErrs, fmt.Errorf("context %v not found", name))
-			}
-		}
-	}
 	if o.showHeaders {
-		err = printAuthInfoHeaders(out, o.nameOnly)
+		err = printAuthInfoHeaders(out, o.nameOnly, o.wide, o.sources != nil)
 		if err != nil {
 			allErrs = append(allErrs, err)
 		}
 	}
 
-	sort.Strings(toPrint)
 	for _, name := range toPrint {
-		currentContext := config.Contexts[config.CurrentContext]
-		err = printAuthInfo(name, config.AuthInfos[name], out, o.nameOnly, currentContext.AuthInfo == name)
+		currentContext := kubeconfig.CurrentContext(config)
+		err = printAuthInfo(name, config, o.expiryWarning, o.style, out, o.nameOnly, o.wide, currentContext.AuthInfo == name, o.sources.sourceCells(selector.AuthInfo, name)...)
 		if err != nil {
 			allErrs = append(allErrs, err)
 		}
+		if o.nameOnly {
+			continue
+		}
+		for _, shadowed := range o.sources.shadowed(config, selector.AuthInfo, name) {
+			err = printAuthInfo(name, shadowed.config, o.expiryWarning, o.style, out, false, o.wide, false, shadowed.file+" (shadowed)")
+			if err != nil {
+				allErrs = append(allErrs, err)
+			}
+		}
 	}
 
 	return utilerrors.NewAggregate(allErrs)
 }
 
-func printAuthInfoHeaders(out io.Writer, nameOnly bool) error {
-	columnNames := []string{"CURRENT", "AUTH_INFO_NAME", "USERNAME"}
+func printAuthInfoHeaders(out io.Writer, nameOnly, wide, showSource bool) error {
+	columnNames := []string{"CURRENT", "AUTH_INFO_NAME", "AUTH_TYPE", "SUBJECT", "GROUPS", "EXPIRES"}
+	if wide {
+		columnNames = append(columnNames, "CONTEXTS", "CLUSTERS")
+	}
+	if showSource {
+		columnNames = append(columnNames, "SOURCE")
+	}
 	if nameOnly {
 		columnNames = columnNames[:1]
 	}
@@ -145,20 +205,77 @@ func printAuthInfoHeaders(out io.Writer, nameOnly bool) error {
 	return err
 }
 
-func printAuthInfo(name string, authInfo *clientcmdapi.AuthInfo, w io.Writer, nameOnly, current bool) error {
+func printAuthInfo(name string, config *clientcmdapi.Config, expiryWarning time.Duration, style *tableStyle, w io.Writer, nameOnly, wide, current bool, source ...interface{}) error {
 	if nameOnly {
 		_, err := fmt.Fprintf(w, "%s\n", name)
 		return err
 	}
-	prefix := " "
 
-	var err error
-	if current {
-		prefix = "*"
-		_, err = fmt.Fprintf(w, "%s\t%s\t%s\n", Green(prefix), Green(name), Green(authInfo.Username))
-	} else {
-		_, err = fmt.Fprintf(w, "%s\t%s\t%s\n", prefix, name, authInfo.Username)
+	authInfo := config.AuthInfos[name]
+	var expires interface{} = ""
+	credential, err := kubeconfig.AuthInfoCredential(authInfo)
+	if err != nil {
+		expires = style.colors.Expired("INVALID")
+	} else if !credential.NotAfter.IsZero() {
+		expires = style.colorExpiry(cert.FormatDate(credential.NotAfter), credential.NotAfter, expiryWarning)
 	}
+	groups := strings.Join(credential.Groups, ",")
+	contexts := kubeconfig.ContextsUsingAuthInfo(config, name)
+	clusters := kubeconfig.ClustersUsedByAuthInfo(config, name)
+	production := style.isProduction(append(contexts, clusters...)...)
 
-	return err
+	cells := []interface{}{name, kubeconfig.AuthMethod(authInfo), credential.Subject, groups, expires}
+	if wide {
+		cells = append(cells, strings.Join(contexts, ","), strings.Join(clusters, ","))
+	}
+	return printRow(w, style, current, production, append(cells, source...)...)
+}
+
+// authInfoItem is the structured form of an auth info printed by the json, yaml, template and custom-columns outputs.
+type authInfoItem struct {
+	Name     string `json:"name"`
+	Current  bool   `json:"current"`
+	Username string `json:"username,omitempty"`
+
+	AuthMethod string   `json:"authMethod"`
+	Subject    string   `json:"subject,omitempty"`
+	Groups     []string `json:"groups,omitempty"`
+	Expires    string   `json:"expires,omitempty"`
+
+	Contexts []string `json:"contexts"`
+	Clusters []string `json:"clusters"`
+
+	Source          string   `json:"source,omitempty"`
+	ShadowedSources []string `json:"shadowedSources,omitempty"`
+}
+
+func (o *ListAuthInfoOptions) toItems(config *clientcmdapi.Config, names []string) []interface{} {
+	currentContext := kubeconfig.CurrentContext(config)
+	items := []interface{}{}
+	for _, name := range names {
+		authInfo := config.AuthInfos[name]
+		item := &authInfoItem{
+			Name:     name,
+			Current:  currentContext.AuthInfo == name,
+			Username: authInfo.Username,
+
+			AuthMethod: kubeconfig.AuthMethod(authInfo),
+
+			Contexts: kubeconfig.ContextsUsingAuthInfo(config, name),
+			Clusters: kubeconfig.ClustersUsedByAuthInfo(config, name),
+		}
+		if credential, err := kubeconfig.AuthInfoCredential(authInfo); err == nil {
+			item.Subject = credential.Subject
+			item.Groups = credential.Groups
+			if !credential.NotAfter.IsZero() {
+				item.Expires = credential.NotAfter.UTC().Format(time.RFC3339)
+			}
+		}
+		if sources := o.sources.sources(selector.AuthInfo, name); len(sources) != 0 {
+			item.Source = sources[0]
+			item.ShadowedSources = sources[1:]
+		}
+		items = append(items, item)
+	}
+	return items
 }
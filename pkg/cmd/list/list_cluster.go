@@ -3,16 +3,19 @@ package list
 import (
 	"fmt"
 	"io"
-	"sort"
 	"strings"
+	"time"
 
+	"github.com/it2911/kubectl-cfg/pkg/util/cert"
+	"github.com/it2911/kubectl-cfg/pkg/util/health"
+	"github.com/it2911/kubectl-cfg/pkg/util/kubeconfig"
 	"github.com/it2911/kubectl-cfg/pkg/util/printers"
+	"github.com/it2911/kubectl-cfg/pkg/util/selector"
 	"github.com/juju/ansiterm"
-	. "github.com/logrusorgru/aurora"
 	"github.com/spf13/cobra"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
-	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cliprinters "k8s.io/cli-runtime/pkg/printers"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	. "k8s.io/kubectl/pkg/cmd/config"
@@ -25,15 +28,54 @@ var (
 
 	listClustersExample = templates.Examples(`
 		# List all the clusters in your kubeconfig file
-		kubectl cfg list cluster`)
+		kubectl cfg list cluster
+
+		# List the clusters without probing their API servers
+		kubectl cfg list cluster --probe=false
+
+		# Probe 20 clusters at a time and give each one 10 seconds to answer
+		kubectl cfg list cluster --probe-workers=20 --probe-timeout=10s
+
+		# Probe the clusters with the credentials of the exec plugins, like aws or gke-gcloud-auth-plugin
+		kubectl cfg list cluster --probe-exec
+
+		# Highlight the certificate authorities which expire within the next 90 days
+		kubectl cfg list cluster --expiry-warning=2160h
+
+		# List the clusters whose certificate authority expires first
+		kubectl cfg list cluster --sort-by=expiry
+
+		# List the clusters with the contexts which reference them
+		kubectl cfg list cluster -o wide`)
 )
 
+// clusterSortColumns are the columns list cluster can be sorted by.
+var clusterSortColumns = map[string]string{
+	"name":    "{.name}",
+	"server":  "{.server}",
+	"status":  "{.status}",
+	"expiry":  "{.certificateAuthorityValidTo}",
+	"version": "{.version}",
+}
+
 // ListClusterOptions contains the assignable options from the args.
 type ListClusterOptions struct {
-	configAccess clientcmd.ConfigAccess
-	nameOnly     bool
-	showHeaders  bool
-	clusterNames []string
+	configAccess  clientcmd.ConfigAccess
+	nameOnly      bool
+	wide          bool
+	showHeaders   bool
+	printFlags    *genericclioptions.PrintFlags
+	printer       cliprinters.ResourcePrinter
+	filterOptions selector.Options
+	filter        *selector.Filter
+	sortBy        string
+	reverse       bool
+	style         *tableStyle
+	showSource    bool
+	sources       *sourceIndex
+	probe         bool
+	probeOptions  health.Options
+	expiryWarning time.Duration
 
 	genericclioptions.IOStreams
 }
@@ -41,26 +83,18 @@ type ListClusterOptions struct {
 func NewCmdCfgListCluster(streams genericclioptions.IOStreams, configAccess clientcmd.ConfigAccess) *cobra.Command {
 	options := &ListClusterOptions{
 		configAccess: configAccess,
+		printFlags:   newPrintFlags(),
 		IOStreams:    streams,
 	}
 
 	cmd := &cobra.Command{
-		Use:                   "cluster",
+		Use:                   "cluster [NAME_PATTERN...]",
 		DisableFlagsInUseLine: true,
 		Short:                 "Describe cluster from the kubeconfig file",
 		Long:                  listClustersLong,
 		Example:               listClustersExample,
 		Run: func(cmd *cobra.Command, args []string) {
-			validOutputTypes := sets.NewString("", "json", "yaml", "wide", "name", "custom-columns", "custom-columns-file", "go-template", "go-template-file", "jsonpath", "jsonpath-file")
-			supportedOutputTypes := sets.NewString("", "name")
-			outputFormat := cmdutil.GetFlagString(cmd, "output")
-			if !validOutputTypes.Has(outputFormat) {
-				cmdutil.CheckErr(fmt.Errorf("output must be one of '' or 'name': %v", outputFormat))
-			}
-			if !supportedOutputTypes.Has(outputFormat) {
-				fmt.Fprintf(options.Out, "--output %v is not available in kubectl config get-contexts; resetting to default output format\n", outputFormat)
-				cmd.Flags().Set("output", "")
-			}
+			cmdutil.CheckErr(validateOutput(cmd))
 			cmdutil.CheckErr(options.Complete(cmd, args))
 			cmdutil.CheckErr(options.RunList())
 		},
@@ -68,23 +102,60 @@ func NewCmdCfgListCluster(streams genericclioptions.IOStreams, configAccess clie
 
 	_ = NewCmdConfigSetCluster(nil, nil)
 
-	cmd.Flags().Bool("no-headers", false, "When using the default or custom-column output format, don't print headers (default print headers).")
-	cmd.Flags().StringP("output", "o", "", "Output format. One of: name")
+	addOutputFlags(cmd, options.printFlags)
+	options.filterOptions.AddFlags(cmd)
+	addSortFlags(cmd, &options.sortBy, &options.reverse, clusterSortColumns)
+	cmd.Flags().BoolVar(&options.showSource, "show-source", options.showSource, "Show the kubeconfig file defining every entry, and the entries shadowed by an earlier file of KUBECONFIG")
+	cmd.Flags().BoolVar(&options.probe, "probe", true, "Probe the /healthz, /readyz and /version endpoints of every cluster to fill the STATUS_CODE column")
+	cmd.Flags().IntVar(&options.probeOptions.Workers, "probe-workers", health.DefaultWorkers, "Number of clusters probed concurrently")
+	cmd.Flags().DurationVar(&options.probeOptions.Timeout, "probe-timeout", health.DefaultTimeout, "Time allowed to probe a single cluster")
+	cmd.Flags().BoolVar(&options.probeOptions.Exec, "probe-exec", false, "Run the exec credential plugins of the users to probe their clusters, which are otherwise probed anonymously")
+	cmd.Flags().DurationVar(&options.expiryWarning, "expiry-warning", cert.DefaultExpiryWarning, "Highlight the certificate authorities which expire within this duration")
 	return cmd
 }
 
 // Complete assigns ListClustersOptions from the args.
 func (o *ListClusterOptions) Complete(cmd *cobra.Command, args []string) error {
-	o.clusterNames = args
 	o.nameOnly = false
 	if cmdutil.GetFlagString(cmd, "output") == "name" {
 		o.nameOnly = true
 	}
+	o.wide = cmdutil.GetFlagString(cmd, "output") == "wide"
 	o.showHeaders = true
 	if cmdutil.GetFlagBool(cmd, "no-headers") || o.nameOnly {
 		o.showHeaders = false
 	}
 
+	if !o.probe && sortsByProbe(o.sortBy) {
+		return cmdutil.UsageErrorf(cmd, "--sort-by=%s sorts by the probe results and cannot be used with --probe=false", o.sortBy)
+	}
+
+	filter, err := o.filterOptions.ToFilter(selector.Cluster, args)
+	if err != nil {
+		return err
+	}
+	o.filter = filter
+
+	style, err := newTableStyle(cmd, o.Out)
+	if err != nil {
+		return err
+	}
+	o.style = style
+
+	sources, err := loadSources(o.configAccess, o.showSource, *o.printFlags.OutputFormat)
+	if err != nil {
+		return err
+	}
+	o.sources = sources
+
+	if isStructuredOutput(*o.printFlags.OutputFormat) {
+		printer, err := toPrinter(o.printFlags, cmdutil.GetFlagBool(cmd, "no-headers"))
+		if err != nil {
+			return err
+		}
+		o.printer = printer
+	}
+
 	return nil
 }
 
@@ -96,51 +167,79 @@ func (o *ListClusterOptions) RunList() error {
 		return err
 	}
 
+	// Build a list of names to print, and warn if any requested names are not found.
+	// Do this before printing the headers so it doesn't look ugly.
+	toPrint, allErrs := o.filter.Select(config)
+
+	results := map[string]health.Result{}
+	// the names alone are printed without probing, unless they are sorted by the probe results
+	if o.probe && (!o.nameOnly || sortsByProbe(o.sortBy)) {
+		results = health.ProbeClusters(config, toPrint, o.probeOptions)
+	}
+
+	toPrint, items, err := sortNames(toPrint, o.toItems(config, toPrint, results), o.sortBy, clusterSortColumns, o.reverse)
+	if err != nil {
+		return err
+	}
+
+	if o.printer != nil {
+		if err := printItems(o.printer, items, o.Out); err != nil {
+			allErrs = append(allErrs, err)
+		}
+		return utilerrors.NewAggregate(allErrs)
+	}
+
 	out, found := o.Out.(*ansiterm.TabWriter)
 	if !found {
 		out = printers.GetNewTabWriter(o.Out)
 		defer out.Flush()
 	}
 
-	// Build a list of context names to print, and warn if any requested contexts are not found.
-	// Do this before printing the headers so it doesn't look ugly.
-	allErrs := []error{}
-	toPrint := []string{}
-	if len(o.clusterNames) == 0 {
-		for name := range config.Clusters {
-			toPrint = append(toPrint, name)
-		}
-	} else {
-		for _, name := range o.clusterNames {
-			_, ok := config.Clusters[name]
-			if ok {
-				toPrint = append(toPrint, name)
-			} else {
-				allErrs = append(allErrs, fmt.Errorf("context %v not found", name))
-			}
-		}
-	}
 	if o.showHeaders {
-		err = printClusterHeaders(out, o.nameOnly)
+		err = printClusterHeaders(out, o.nameOnly, o.wide, o.sources != nil)
 		if err != nil {
 			allErrs = append(allErrs, err)
 		}
 	}
 
-	sort.Strings(toPrint)
 	for _, name := range toPrint {
-		currentContext := config.Contexts[config.CurrentContext]
-		err = printCluster(name, config.Clusters[name], out, o.nameOnly, currentContext.Cluster == name)
+		currentContext := kubeconfig.CurrentContext(config)
+		err = printCluster(name, config, results[name], o.expiryWarning, o.style, out, o.nameOnly, o.wide, currentContext.Cluster == name, o.sources.sourceCells(selector.Cluster, name)...)
 		if err != nil {
 			allErrs = append(allErrs, err)
 		}
+		if o.nameOnly {
+			continue
+		}
+		// the shadowed definitions are never used, so they are not probed
+		for _, shadowed := range o.sources.shadowed(config, selector.Cluster, name) {
+			err = printCluster(name, shadowed.config, health.Result{}, o.expiryWarning, o.style, out, false, o.wide, false, shadowed.file+" (shadowed)")
+			if err != nil {
+				allErrs = append(allErrs, err)
+			}
+		}
 	}
 
 	return utilerrors.NewAggregate(allErrs)
 }
 
-func printClusterHeaders(out io.Writer, nameOnly bool) error {
+// sortsByProbe tells whether the clusters are sorted by a column only filled by probing them.
+func sortsByProbe(sortBy string) bool {
+	fieldSpec, ok := clusterSortColumns[sortBy]
+	if !ok {
+		fieldSpec, _ = printers.RelaxedJSONPathExpression(sortBy)
+	}
+	return fieldSpec == clusterSortColumns["status"] || fieldSpec == clusterSortColumns["version"]
+}
+
+func printClusterHeaders(out io.Writer, nameOnly, wide, showSource bool) error {
 	columnNames := []string{"CURRENT", "CLUSTER_NAME", "SERVER", "STATUS_CODE", "CERTIFICATE_AUTHORITY_VALIDITY_TO"}
+	if wide {
+		columnNames = append(columnNames, "VERSION", "CONTEXTS")
+	}
+	if showSource {
+		columnNames = append(columnNames, "SOURCE")
+	}
 	if nameOnly {
 		columnNames = columnNames[:1]
 	}
@@ -148,27 +247,81 @@ func printClusterHeaders(out io.Writer, nameOnly bool) error {
 	return err
 }
 
-func printCluster(name string, cluster *clientcmdapi.Cluster, w io.Writer, nameOnly, current bool) error {
+func printCluster(name string, config *clientcmdapi.Config, result health.Result, expiryWarning time.Duration, style *tableStyle, w io.Writer, nameOnly, wide, current bool, source ...interface{}) error {
 	if nameOnly {
 		_, err := fmt.Fprintf(w, "%s\n", name)
 		return err
 	}
 
-	prefix := " "
-	statusCode := "UNKNOW"
-	//resp, err := http.Get(cluster.Server)
-	//if err != nil{
-	//
-	//}
-	//statusCode = fmt.Sprint(resp.StatusCode)
+	cluster := config.Clusters[name]
+	var statusCode interface{} = result.Status()
+	switch result.Status() {
+	case "":
+		statusCode = health.StatusUnknown
+	case "200":
+	default:
+		statusCode = style.colors.Unreachable(result.Status())
+	}
 
-	var err error
-	if current {
-		prefix = "*"
-		_, err = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", Green(prefix), Green(name), Green(cluster.Server), Green(statusCode), "")
-	} else {
-		_, err = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", prefix, name, cluster.Server, statusCode, "")
+	var validity interface{} = ""
+	certs, err := cert.LoadCertificates(cluster.CertificateAuthorityData, cluster.CertificateAuthority)
+	if err != nil {
+		validity = style.colors.Expired("INVALID")
+	} else if shortest, err := cert.Shortest(certs); err == nil {
+		validity = style.colorExpiry(cert.FormatValidity(certs), shortest.NotAfter, expiryWarning)
 	}
 
-	return err
+	contexts := kubeconfig.ContextsUsingCluster(config, name)
+	production := style.isProduction(append(contexts, name)...)
+	cells := []interface{}{name, cluster.Server, statusCode, validity}
+	if wide {
+		cells = append(cells, result.Version, strings.Join(contexts, ","))
+	}
+	return printRow(w, style, current, production, append(cells, source...)...)
+}
+
+// clusterItem is the structured form of a cluster printed by the json, yaml, template and custom-columns outputs.
+type clusterItem struct {
+	Name                        string `json:"name"`
+	Current                     bool   `json:"current"`
+	Server                      string `json:"server"`
+	InsecureSkipTLSVerify       bool   `json:"insecureSkipTLSVerify,omitempty"`
+	Status                      string `json:"status,omitempty"`
+	Version                     string `json:"version,omitempty"`
+	CertificateAuthorityValidTo string `json:"certificateAuthorityValidTo,omitempty"`
+
+	Contexts []string `json:"contexts"`
+
+	Source          string   `json:"source,omitempty"`
+	ShadowedSources []string `json:"shadowedSources,omitempty"`
+}
+
+func (o *ListClusterOptions) toItems(config *clientcmdapi.Config, names []string, results map[string]health.Result) []interface{} {
+	currentContext := kubeconfig.CurrentContext(config)
+	items := []interface{}{}
+	for _, name := range names {
+		cluster := config.Clusters[name]
+		item := &clusterItem{
+			Name:                  name,
+			Current:               currentContext.Cluster == name,
+			Server:                cluster.Server,
+			InsecureSkipTLSVerify: cluster.InsecureSkipTLSVerify,
+			Status:                results[name].Status(),
+			Version:               results[name].Version,
+
+			Contexts: kubeconfig.ContextsUsingCluster(config, name),
+		}
+		certs, err := cert.LoadCertificates(cluster.CertificateAuthorityData, cluster.CertificateAuthority)
+		if err == nil {
+			if shortest, err := cert.Shortest(certs); err == nil {
+				item.CertificateAuthorityValidTo = shortest.NotAfter.UTC().Format(time.RFC3339)
+			}
+		}
+		if sources := o.sources.sources(selector.Cluster, name); len(sources) != 0 {
+			item.Source = sources[0]
+			item.ShadowedSources = sources[1:]
+		}
+		items = append(items, item)
+	}
+	return items
 }
@@ -3,16 +3,16 @@ package list
 import (
 	"fmt"
 	"io"
-	"sort"
 	"strings"
 
+	"github.com/it2911/kubectl-cfg/pkg/util/kubeconfig"
 	"github.com/it2911/kubectl-cfg/pkg/util/printers"
+	"github.com/it2911/kubectl-cfg/pkg/util/selector"
 	"github.com/juju/ansiterm"
-	. "github.com/logrusorgru/aurora"
 	"github.com/spf13/cobra"
 	utilerrors "k8s.io/apimachinery/pkg/util/errors"
-	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	cliprinters "k8s.io/cli-runtime/pkg/printers"
 	"k8s.io/client-go/tools/clientcmd"
 	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
@@ -24,15 +24,58 @@ var (
 
 	listContextsExample = templates.Examples(`
 		# List all the contexts in your kubeconfig file
-		kubectl cfg list context`)
+		kubectl cfg list context
+
+		# List the contexts whose name starts with eks-
+		kubectl cfg list context 'eks-*'
+
+		# List the contexts of the prod clusters which use the kube-system namespace
+		kubectl cfg list context --selector='cluster=prod-*,namespace=kube-system'
+
+		# List the contexts whose server is hosted on amazonaws.com
+		kubectl cfg list context --server-matches='\.amazonaws\.com'
+
+		# List the contexts ordered by server, to spot the ones pointing at the same cluster
+		kubectl cfg list context --sort-by=server
+
+		# List the contexts as JSON
+		kubectl cfg list context -o json
+
+		# List the contexts with the server and auth method they really use
+		kubectl cfg list context -o wide
+
+		# List the contexts with the file of KUBECONFIG defining each one
+		kubectl cfg list context --show-source
+
+		# List the server of every context
+		kubectl cfg list context -o custom-columns=NAME:.name,SERVER:.server`)
 )
 
+// contextSortColumns are the columns list context can be sorted by.
+var contextSortColumns = map[string]string{
+	"name":      "{.name}",
+	"cluster":   "{.cluster}",
+	"user":      "{.user}",
+	"namespace": "{.namespace}",
+	"server":    "{.server}",
+	"auth":      "{.authMethod}",
+}
+
 // ListContextsOptions contains the assignable options from the args.
 type ListContextOptions struct {
-	configAccess clientcmd.ConfigAccess
-	nameOnly     bool
-	showHeaders  bool
-	contextNames []string
+	configAccess  clientcmd.ConfigAccess
+	nameOnly      bool
+	wide          bool
+	showHeaders   bool
+	printFlags    *genericclioptions.PrintFlags
+	printer       cliprinters.ResourcePrinter
+	filterOptions selector.Options
+	filter        *selector.Filter
+	sortBy        string
+	reverse       bool
+	style         *tableStyle
+	showSource    bool
+	sources       *sourceIndex
 
 	genericclioptions.IOStreams
 }
@@ -42,48 +85,68 @@ type ListContextOptions struct {
 func NewCmdCfgListContext(streams genericclioptions.IOStreams, configAccess clientcmd.ConfigAccess) *cobra.Command {
 	options := &ListContextOptions{
 		configAccess: configAccess,
+		printFlags:   newPrintFlags(),
 		IOStreams:    streams,
 	}
 
 	cmd := &cobra.Command{
-		Use:                   "context",
+		Use:                   "context [NAME_PATTERN...]",
 		DisableFlagsInUseLine: true,
 		Short:                 "Describe context from the kubeconfig file",
 		Long:                  listContextsLong,
 		Example:               listContextsExample,
 		Run: func(cmd *cobra.Command, args []string) {
-			validOutputTypes := sets.NewString("", "json", "yaml", "wide", "name", "custom-columns", "custom-columns-file", "go-template", "go-template-file", "jsonpath", "jsonpath-file")
-			supportedOutputTypes := sets.NewString("", "name")
-			outputFormat := cmdutil.GetFlagString(cmd, "output")
-			if !validOutputTypes.Has(outputFormat) {
-				cmdutil.CheckErr(fmt.Errorf("output must be one of '' or 'name': %v", outputFormat))
-			}
-			if !supportedOutputTypes.Has(outputFormat) {
-				fmt.Fprintf(options.Out, "--output %v is not available in kubectl config get-contexts; resetting to default output format\n", outputFormat)
-				cmd.Flags().Set("output", "")
-			}
+			cmdutil.CheckErr(validateOutput(cmd))
 			cmdutil.CheckErr(options.Complete(cmd, args))
 			cmdutil.CheckErr(options.RunList())
 		},
 	}
 
-	cmd.Flags().Bool("no-headers", false, "When using the default or custom-column output format, don't print headers (default print headers).")
-	cmd.Flags().StringP("output", "o", "", "Output format. One of: name")
+	addOutputFlags(cmd, options.printFlags)
+	options.filterOptions.AddFlags(cmd)
+	addSortFlags(cmd, &options.sortBy, &options.reverse, contextSortColumns)
+	cmd.Flags().BoolVar(&options.showSource, "show-source", options.showSource, "Show the kubeconfig file defining every entry, and the entries shadowed by an earlier file of KUBECONFIG")
 	return cmd
 }
 
 // Complete assigns ListContextsOptions from the args.
 func (o *ListContextOptions) Complete(cmd *cobra.Command, args []string) error {
-	o.contextNames = args
 	o.nameOnly = false
 	if cmdutil.GetFlagString(cmd, "output") == "name" {
 		o.nameOnly = true
 	}
+	o.wide = cmdutil.GetFlagString(cmd, "output") == "wide"
 	o.showHeaders = true
 	if cmdutil.GetFlagBool(cmd, "no-headers") || o.nameOnly {
 		o.showHeaders = false
 	}
 
+	filter, err := o.filterOptions.ToFilter(selector.Context, args)
+	if err != nil {
+		return err
+	}
+	o.filter = filter
+
+	style, err := newTableStyle(cmd, o.Out)
+	if err != nil {
+		return err
+	}
+	o.style = style
+
+	sources, err := loadSources(o.configAccess, o.showSource, *o.printFlags.OutputFormat)
+	if err != nil {
+		return err
+	}
+	o.sources = sources
+
+	if isStructuredOutput(*o.printFlags.OutputFormat) {
+		printer, err := toPrinter(o.printFlags, cmdutil.GetFlagBool(cmd, "no-headers"))
+		if err != nil {
+			return err
+		}
+		o.printer = printer
+	}
+
 	return nil
 }
 
@@ -95,50 +158,61 @@ func (o *ListContextOptions) RunList() error {
 		return err
 	}
 
+	// Build a list of names to print, and warn if any requested names are not found.
+	// Do this before printing the headers so it doesn't look ugly.
+	toPrint, allErrs := o.filter.Select(config)
+	toPrint, items, err := sortNames(toPrint, o.toItems(config, toPrint), o.sortBy, contextSortColumns, o.reverse)
+	if err != nil {
+		return err
+	}
+
+	if o.printer != nil {
+		if err := printItems(o.printer, items, o.Out); err != nil {
+			allErrs = append(allErrs, err)
+		}
+		return utilerrors.NewAggregate(allErrs)
+	}
+
 	out, found := o.Out.(*ansiterm.TabWriter)
 	if !found {
 		out = printers.GetNewTabWriter(o.Out)
 		defer out.Flush()
 	}
 
-	// Build a list of context names to print, and warn if any requested contexts are not found.
-	// Do this before printing the headers so it doesn't look ugly.
-	allErrs := []error{}
-	toPrint := []string{}
-	if len(o.contextNames) == 0 {
-		for name := range config.Contexts {
-			toPrint = append(toPrint, name)
-		}
-	} else {
-		for _, name := range o.contextNames {
-			_, ok := config.Contexts[name]
-			if ok {
-				toPrint = append(toPrint, name)
-			} else {
-				allErrs = append(allErrs, fmt.Errorf("context %v not found", name))
-			}
-		}
-	}
 	if o.showHeaders {
-		err = printContextHeaders(out, o.nameOnly)
+		err = printContextHeaders(out, o.nameOnly, o.wide, o.sources != nil)
 		if err != nil {
 			allErrs = append(allErrs, err)
 		}
 	}
 
-	sort.Strings(toPrint)
 	for _, name := range toPrint {
-		err = printContext(name, config.Contexts[name], out, o.nameOnly, config.CurrentContext == name)
+		err = printContext(name, config, o.style, out, o.nameOnly, o.wide, config.CurrentContext == name, o.sources.sourceCells(selector.Context, name)...)
 		if err != nil {
 			allErrs = append(allErrs, err)
 		}
+		if o.nameOnly {
+			continue
+		}
+		for _, shadowed := range o.sources.shadowed(config, selector.Context, name) {
+			err = printContext(name, shadowed.config, o.style, out, false, o.wide, false, shadowed.file+" (shadowed)")
+			if err != nil {
+				allErrs = append(allErrs, err)
+			}
+		}
 	}
 
 	return utilerrors.NewAggregate(allErrs)
 }
 
-func printContextHeaders(out io.Writer, nameOnly bool) error {
+func printContextHeaders(out io.Writer, nameOnly, wide, showSource bool) error {
 	columnNames := []string{"CURRENT", "CONTEXT_NAME", "CLUSTER_NAME", "AUTH_INFO", "DEFAULT_NAMESPACE"}
+	if wide {
+		columnNames = append(columnNames, "SERVER", "AUTH_METHOD", "EXEC_COMMAND", "EXTENSIONS")
+	}
+	if showSource {
+		columnNames = append(columnNames, "SOURCE")
+	}
 	if nameOnly {
 		columnNames = columnNames[:1]
 	}
@@ -146,18 +220,69 @@ func printContextHeaders(out io.Writer, nameOnly bool) error {
 	return err
 }
 
-func printContext(name string, context *clientcmdapi.Context, w io.Writer, nameOnly, current bool) error {
+func printContext(name string, config *clientcmdapi.Config, style *tableStyle, w io.Writer, nameOnly, wide, current bool, source ...interface{}) error {
 	if nameOnly {
 		_, err := fmt.Fprintf(w, "%s\n", name)
 		return err
 	}
-	prefix := " "
-	var err error
-	if current {
-		prefix = "*"
-		_, err = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", Green(prefix), Green(name), Green(context.Cluster), Green(context.AuthInfo), Green(context.Namespace))
-	} else {
-		_, err = fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", prefix, name, context.Cluster, context.AuthInfo, context.Namespace)
+
+	context := config.Contexts[name]
+	production := style.isProduction(name, context.Cluster)
+	cells := []interface{}{name, context.Cluster, context.AuthInfo, context.Namespace}
+	if !wide {
+		return printRow(w, style, current, production, append(cells, source...)...)
 	}
-	return err
+
+	server := ""
+	if cluster, ok := config.Clusters[context.Cluster]; ok {
+		server = cluster.Server
+	}
+	authInfo := config.AuthInfos[context.AuthInfo]
+	extensions := strings.Join(kubeconfig.ExtensionNames(context.Extensions), ",")
+	cells = append(cells, server, kubeconfig.AuthMethod(authInfo), kubeconfig.ExecCommand(authInfo), extensions)
+	return printRow(w, style, current, production, append(cells, source...)...)
+}
+
+// contextItem is the structured form of a context printed by the json, yaml, template and custom-columns outputs.
+type contextItem struct {
+	Name      string `json:"name"`
+	Current   bool   `json:"current"`
+	Cluster   string `json:"cluster"`
+	AuthInfo  string `json:"user"`
+	Namespace string `json:"namespace,omitempty"`
+	Server    string `json:"server,omitempty"`
+
+	AuthMethod  string   `json:"authMethod"`
+	ExecCommand string   `json:"execCommand,omitempty"`
+	Extensions  []string `json:"extensions,omitempty"`
+
+	Source          string   `json:"source,omitempty"`
+	ShadowedSources []string `json:"shadowedSources,omitempty"`
+}
+
+func (o *ListContextOptions) toItems(config *clientcmdapi.Config, names []string) []interface{} {
+	items := []interface{}{}
+	for _, name := range names {
+		context := config.Contexts[name]
+		item := &contextItem{
+			Name:      name,
+			Current:   config.CurrentContext == name,
+			Cluster:   context.Cluster,
+			AuthInfo:  context.AuthInfo,
+			Namespace: context.Namespace,
+
+			AuthMethod:  kubeconfig.AuthMethod(config.AuthInfos[context.AuthInfo]),
+			ExecCommand: kubeconfig.ExecCommand(config.AuthInfos[context.AuthInfo]),
+			Extensions:  kubeconfig.ExtensionNames(context.Extensions),
+		}
+		if cluster, ok := config.Clusters[context.Cluster]; ok {
+			item.Server = cluster.Server
+		}
+		if sources := o.sources.sources(selector.Context, name); len(sources) != 0 {
+			item.Source = sources[0]
+			item.ShadowedSources = sources[1:]
+		}
+		items = append(items, item)
+	}
+	return items
 }
@@ -0,0 +1,511 @@
+package merge
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/it2911/kubectl-cfg/pkg/util/kubeconfig"
+	"github.com/it2911/kubectl-cfg/pkg/util/printers"
+	"github.com/it2911/kubectl-cfg/pkg/util/yaml"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+const (
+	conflictSkip      = "skip"
+	conflictOverwrite = "overwrite"
+	conflictRename    = "rename"
+	conflictPrefix    = "prefix"
+)
+
+// the actions of the import report.
+const (
+	actionAdded       = "added"
+	actionIdentical   = "identical"
+	actionSkipped     = "skipped"
+	actionOverwritten = "overwritten"
+	actionRenamed     = "renamed"
+	actionPrefixed    = "prefixed"
+)
+
+var validConflictStrategies = sets.NewString(conflictSkip, conflictOverwrite, conflictRename, conflictPrefix)
+
+var (
+	addConfigFileLong = templates.LongDesc(`
+		Imports the contexts, clusters and authinfos of another kubeconfig file into your kubeconfig file.
+
+		With --context only the given contexts are imported, together with the clusters and authinfos
+		they reference. An entry whose name is already used by a different entry is handled according to
+		--on-conflict:
+
+		* skip: the existing entry is kept and the imported one is dropped (default).
+		* overwrite: the existing entry is replaced by the imported one.
+		* rename: the imported entry gets the first free name-N name.
+		* prefix: the imported entry is prefixed with --prefix, which defaults to the file name.
+
+		The contexts follow the new names of their clusters and authinfos. A context whose cluster or
+		authinfo is skipped is skipped as well, rather than using the different entry of that name.
+
+		Every kubeconfig file which is modified is backed up first, next to the file or into the backup
+		directory set by $KUBECTL_CFG_BACKUP_DIR or the backup.dir of the settings.
+
+		FILE can be - to read the kubeconfig from the standard input, and a base64 encoded kubeconfig is
+		decoded. --from-base64 imports a kubeconfig given as a base64 string instead of FILE.`)
+
+	addConfigFileExample = templates.Examples(`
+		# Import every entry of a new cluster's kubeconfig, skipping the names already in use
+		kubectl cfg add config ./new-cluster.yaml
+
+		# Import only the admin context, renaming the colliding entries
+		kubectl cfg add config ./new-cluster.yaml --context=admin --on-conflict=rename
+
+		# Import every entry, prefixing the colliding names with "staging-"
+		kubectl cfg add config ./new-cluster.yaml --on-conflict=prefix --prefix=staging-
+
+		# Import the kubeconfig handed out by a CI system as base64, without writing it to disk
+		kubectl cfg add config --from-base64="$CI_KUBECONFIG" --prefix=ci- --on-conflict=prefix
+
+		# Import the kubeconfig of a Cluster API secret from the standard input
+		kubectl get secret prod-kubeconfig -o jsonpath='{.data.value}' | kubectl cfg add config - --prefix=prod-`)
+)
+
+// ImportOptions are the options shared by the commands which import entries into the kubeconfig.
+type ImportOptions struct {
+	configAccess clientcmd.ConfigAccess
+	onConflict   string
+	prefix       string
+
+	genericclioptions.IOStreams
+}
+
+// AddConfigOptions contains the assignable options from the args.
+type AddConfigOptions struct {
+	ImportOptions
+	file     string
+	base64   string
+	contexts []string
+}
+
+// importResult is a line of the import report.
+type importResult struct {
+	kind       string
+	name       string
+	action     string
+	importedAs string
+}
+
+// NewCmdCfgAddConfig returns a Command instance for 'cfg add config' sub command
+func NewCmdCfgAddConfig(streams genericclioptions.IOStreams, configAccess clientcmd.ConfigAccess) *cobra.Command {
+	options := &AddConfigOptions{ImportOptions: newImportOptions(streams, configAccess)}
+
+	cmd := &cobra.Command{
+		Use:                   "config FILE|- | --from-base64=BASE64 [--context=NAME] [--on-conflict=skip|overwrite|rename|prefix] [--prefix=PREFIX]",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Import the entries of another kubeconfig file into kubeconfig"),
+		Long:                  addConfigFileLong,
+		Example:               addConfigFileExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(options.Complete(cmd, args))
+			cmdutil.CheckErr(options.Validate())
+			cmdutil.CheckErr(options.RunAddConfig())
+		},
+	}
+
+	cmd.Flags().StringVar(&options.base64, kubeconfig.FlagFromBase64, options.base64, "Base64 encoded kubeconfig to import instead of FILE")
+	cmd.Flags().StringSliceVar(&options.contexts, "context", options.contexts, "Import only these contexts and the clusters and authinfos they reference")
+	options.addImportFlags(cmd, "the file name, or \"imported\" for the standard input and base64")
+	return cmd
+}
+
+func newImportOptions(streams genericclioptions.IOStreams, configAccess clientcmd.ConfigAccess) ImportOptions {
+	return ImportOptions{
+		configAccess: configAccess,
+		onConflict:   conflictSkip,
+		IOStreams:    streams,
+	}
+}
+
+// addImportFlags binds the --on-conflict and --prefix flags, prefixDefault telling what --prefix defaults to.
+func (o *ImportOptions) addImportFlags(cmd *cobra.Command, prefixDefault string) {
+	cmd.Flags().StringVar(&o.onConflict, "on-conflict", o.onConflict, "What to do with an entry whose name is already used. One of: skip|overwrite|rename|prefix")
+	cmd.Flags().StringVar(&o.prefix, "prefix", o.prefix, "Prefix of the colliding names with --on-conflict=prefix, defaults to "+prefixDefault+" followed by a dash")
+}
+
+// Complete assigns AddConfigOptions from the args.
+func (o *AddConfigOptions) Complete(cmd *cobra.Command, args []string) error {
+	switch {
+	case len(args) == 1 && len(o.base64) == 0:
+		o.file = args[0]
+	case len(args) == 0 && len(o.base64) != 0:
+	default:
+		return cmdutil.UsageErrorf(cmd, "exactly one kubeconfig file, or --%s, is required", kubeconfig.FlagFromBase64)
+	}
+
+	if len(o.file) == 0 || o.file == kubeconfig.StdinFile {
+		o.defaultPrefix("imported")
+		return nil
+	}
+	base := filepath.Base(o.file)
+	o.defaultPrefix(strings.TrimSuffix(base, filepath.Ext(base)))
+	return nil
+}
+
+// defaultPrefix sets the prefix of the colliding names unless --prefix is given.
+func (o *ImportOptions) defaultPrefix(name string) {
+	if len(o.prefix) == 0 {
+		o.prefix = name + "-"
+	}
+}
+
+// Validate checks the conflict strategy.
+func (o *ImportOptions) Validate() error {
+	if !validConflictStrategies.Has(o.onConflict) {
+		return fmt.Errorf("--on-conflict must be one of %s: %v", strings.Join(validConflictStrategies.List(), ", "), o.onConflict)
+	}
+	return nil
+}
+
+// RunAddConfig imports the entries of the file into the kubeconfig.
+func (o *AddConfigOptions) RunAddConfig() error {
+	var imported *clientcmdapi.Config
+	var err error
+	source := o.file
+	if len(o.base64) != 0 {
+		source = "--" + kubeconfig.FlagFromBase64
+		imported, err = kubeconfig.LoadBase64(o.base64)
+	} else {
+		if o.file == kubeconfig.StdinFile {
+			source = "the standard input"
+		}
+		// the relative paths of the imported file are resolved, so that they keep pointing at the same
+		// files once written elsewhere
+		imported, err = kubeconfig.LoadInput(o.file, o.In)
+	}
+	if err != nil {
+		return err
+	}
+	if err := selectContexts(imported, o.contexts, source); err != nil {
+		return err
+	}
+
+	return o.RunImport(imported, source)
+}
+
+// readFile reads a file argument, "-" standing for in.
+func readFile(file string, in io.Reader) ([]byte, error) {
+	if file == kubeconfig.StdinFile {
+		return ioutil.ReadAll(in)
+	}
+	return ioutil.ReadFile(file)
+}
+
+// RunImport imports the entries of a config read from source into the kubeconfig, backs the modified
+// files up and prints the import report.
+func (o *ImportOptions) RunImport(imported *clientcmdapi.Config, source string) error {
+	config, err := o.configAccess.GetStartingConfig()
+	if err != nil {
+		return err
+	}
+
+	i := &importer{config: config, strategy: o.onConflict, prefix: o.prefix}
+	i.importConfig(imported)
+	for _, warning := range i.warnings {
+		fmt.Fprintln(o.ErrOut, warning)
+	}
+
+	if i.destinations.Len() == 0 {
+		if err := printImportResults(o.Out, i.results); err != nil {
+			return err
+		}
+		fmt.Fprintf(o.Out, "nothing to import from %s\n", source)
+		return nil
+	}
+
+	for _, destination := range i.destinations.List() {
+		if len(destination) == 0 {
+			destination = o.configAccess.GetDefaultFilename()
+		}
+		backupFile, err := yaml.BackupFile(o.ErrOut, destination)
+		if err != nil {
+			return fmt.Errorf("backup of %s failed, nothing was imported: %v", destination, err)
+		}
+		if len(backupFile) != 0 {
+			fmt.Fprintf(o.ErrOut, "info: %s backup to %s\n", destination, backupFile)
+		}
+	}
+
+	if err := clientcmd.ModifyConfig(o.configAccess, *config, true); err != nil {
+		return err
+	}
+
+	if err := printImportResults(o.Out, i.results); err != nil {
+		return err
+	}
+	fmt.Fprintf(o.Out, "imported %s\n", source)
+	return nil
+}
+
+// selectContexts drops the entries of the config which are not needed by the selected contexts.
+// Nothing is dropped when no context is selected.
+func selectContexts(config *clientcmdapi.Config, contexts []string, file string) error {
+	if len(contexts) == 0 {
+		return nil
+	}
+
+	selected := map[string]*clientcmdapi.Context{}
+	clusters := map[string]*clientcmdapi.Cluster{}
+	authInfos := map[string]*clientcmdapi.AuthInfo{}
+	for _, name := range contexts {
+		context, ok := config.Contexts[name]
+		if !ok {
+			return fmt.Errorf("context %q not found in %s", name, file)
+		}
+		selected[name] = context
+		if cluster, ok := config.Clusters[context.Cluster]; ok {
+			clusters[context.Cluster] = cluster
+		}
+		if authInfo, ok := config.AuthInfos[context.AuthInfo]; ok {
+			authInfos[context.AuthInfo] = authInfo
+		}
+	}
+
+	config.Contexts = selected
+	config.Clusters = clusters
+	config.AuthInfos = authInfos
+	return nil
+}
+
+// importer merges imported entries into a config according to a conflict strategy.
+type importer struct {
+	config   *clientcmdapi.Config
+	strategy string
+	prefix   string
+
+	results  []importResult
+	warnings []string
+	// destinations are the files the imported entries are written to, the empty one standing for the default file.
+	destinations sets.String
+}
+
+// importConfig imports the clusters and the authinfos first, so that the contexts can follow their new names.
+func (i *importer) importConfig(imported *clientcmdapi.Config) {
+	i.destinations = sets.NewString()
+	skippedContexts, droppedClusters, droppedAuthInfos := i.planSkips(imported)
+
+	clusterNames := map[string]string{}
+	for _, name := range kubeconfig.ClusterNames(imported.Clusters) {
+		if droppedClusters.Has(name) {
+			i.skip("cluster", name, "only skipped contexts reference it")
+			continue
+		}
+		cluster := imported.Clusters[name]
+		existing, exists := i.config.Clusters[name]
+		newName, action := i.resolve("cluster", name, exists && equalClusters(cluster, existing), func(n string) bool {
+			_, ok := i.config.Clusters[n]
+			return ok
+		})
+		clusterNames[name] = newName
+		if i.writes(action) {
+			origin := ""
+			if exists {
+				origin = existing.LocationOfOrigin
+			}
+			cluster.LocationOfOrigin = i.destination(action, origin)
+			i.config.Clusters[newName] = cluster
+		}
+	}
+
+	authInfoNames := map[string]string{}
+	for _, name := range kubeconfig.AuthInfoNames(imported.AuthInfos) {
+		if droppedAuthInfos.Has(name) {
+			i.skip("auth", name, "only skipped contexts reference it")
+			continue
+		}
+		authInfo := imported.AuthInfos[name]
+		existing, exists := i.config.AuthInfos[name]
+		newName, action := i.resolve("auth", name, exists && equalAuthInfos(authInfo, existing), func(n string) bool {
+			_, ok := i.config.AuthInfos[n]
+			return ok
+		})
+		authInfoNames[name] = newName
+		if i.writes(action) {
+			origin := ""
+			if exists {
+				origin = existing.LocationOfOrigin
+			}
+			authInfo.LocationOfOrigin = i.destination(action, origin)
+			i.config.AuthInfos[newName] = authInfo
+		}
+	}
+
+	for _, name := range kubeconfig.ContextNames(imported.Contexts) {
+		if reason, ok := skippedContexts[name]; ok {
+			i.skip("context", name, reason)
+			continue
+		}
+		context := imported.Contexts[name]
+		if newName, ok := clusterNames[context.Cluster]; ok {
+			context.Cluster = newName
+		}
+		if newName, ok := authInfoNames[context.AuthInfo]; ok {
+			context.AuthInfo = newName
+		}
+		existing, exists := i.config.Contexts[name]
+		newName, action := i.resolve("context", name, exists && equalContexts(context, existing), func(n string) bool {
+			_, ok := i.config.Contexts[n]
+			return ok
+		})
+		if i.writes(action) {
+			origin := ""
+			if exists {
+				origin = existing.LocationOfOrigin
+			}
+			context.LocationOfOrigin = i.destination(action, origin)
+			i.config.Contexts[newName] = context
+		}
+	}
+}
+
+// planSkips returns the imported contexts which must be skipped, with the reason, as they reference a cluster
+// or an authinfo skipped for its conflict: the existing entry of that name is a different one, which they
+// must not use. It also returns the clusters and the authinfos which are dropped as only these contexts
+// reference them, so that they are not left orphaned.
+func (i *importer) planSkips(imported *clientcmdapi.Config) (map[string]string, sets.String, sets.String) {
+	skippedClusters := sets.NewString()
+	skippedAuthInfos := sets.NewString()
+	if i.strategy == conflictSkip {
+		for name, cluster := range imported.Clusters {
+			if existing, ok := i.config.Clusters[name]; ok && !equalClusters(cluster, existing) {
+				skippedClusters.Insert(name)
+			}
+		}
+		for name, authInfo := range imported.AuthInfos {
+			if existing, ok := i.config.AuthInfos[name]; ok && !equalAuthInfos(authInfo, existing) {
+				skippedAuthInfos.Insert(name)
+			}
+		}
+	}
+
+	skippedContexts := map[string]string{}
+	usedClusters, usedAuthInfos := sets.NewString(), sets.NewString()
+	orphanedClusters, orphanedAuthInfos := sets.NewString(), sets.NewString()
+	for name, context := range imported.Contexts {
+		skippedReferences := []string{}
+		if skippedClusters.Has(context.Cluster) {
+			skippedReferences = append(skippedReferences, fmt.Sprintf("cluster %q", context.Cluster))
+		}
+		if skippedAuthInfos.Has(context.AuthInfo) {
+			skippedReferences = append(skippedReferences, fmt.Sprintf("auth %q", context.AuthInfo))
+		}
+		if len(skippedReferences) == 0 {
+			usedClusters.Insert(context.Cluster)
+			usedAuthInfos.Insert(context.AuthInfo)
+			continue
+		}
+		skippedContexts[name] = fmt.Sprintf("its %s is skipped", strings.Join(skippedReferences, " and "))
+		orphanedClusters.Insert(context.Cluster)
+		orphanedAuthInfos.Insert(context.AuthInfo)
+	}
+
+	droppedClusters := orphanedClusters.Difference(usedClusters).Difference(skippedClusters)
+	droppedAuthInfos := orphanedAuthInfos.Difference(usedAuthInfos).Difference(skippedAuthInfos)
+	return skippedContexts, droppedClusters, droppedAuthInfos
+}
+
+// skip records an imported entry which is skipped for another reason than its own conflict, and warns about it.
+func (i *importer) skip(kind, name, reason string) {
+	i.results = append(i.results, importResult{kind: kind, name: name, action: actionSkipped})
+	i.warnings = append(i.warnings, fmt.Sprintf("warning: %s %q is skipped as %s", kind, name, reason))
+}
+
+// resolve picks the name an imported entry is stored under and records the result. An identical entry keeps
+// its name, so that the imported contexts reference the existing entry.
+func (i *importer) resolve(kind, name string, identical bool, taken func(string) bool) (string, string) {
+	newName, action := name, actionAdded
+	switch {
+	case !taken(name):
+	case identical:
+		action = actionIdentical
+	case i.strategy == conflictSkip:
+		action = actionSkipped
+	case i.strategy == conflictOverwrite:
+		action = actionOverwritten
+	case i.strategy == conflictRename:
+		newName, action = kubeconfig.FreeName(name, taken), actionRenamed
+	case i.strategy == conflictPrefix:
+		newName, action = i.prefix+name, actionPrefixed
+		if taken(newName) {
+			newName = kubeconfig.FreeName(newName, taken)
+		}
+	}
+
+	importedAs := newName
+	if action == actionSkipped || action == actionIdentical {
+		importedAs = ""
+	}
+	i.results = append(i.results, importResult{kind: kind, name: name, action: action, importedAs: importedAs})
+	return newName, action
+}
+
+// writes tells whether an entry with the action must be written into the kubeconfig.
+func (i *importer) writes(action string) bool {
+	return action != actionSkipped && action != actionIdentical
+}
+
+// destination returns the file an imported entry is written to: the file of the entry it overwrites,
+// or the default file which is named by the empty string.
+func (i *importer) destination(action, origin string) string {
+	destination := ""
+	if action == actionOverwritten {
+		destination = origin
+	}
+	i.destinations.Insert(destination)
+	return destination
+}
+
+func equalClusters(a, b *clientcmdapi.Cluster) bool {
+	x, y := *a, *b
+	x.LocationOfOrigin, y.LocationOfOrigin = "", ""
+	return reflect.DeepEqual(x, y)
+}
+
+func equalAuthInfos(a, b *clientcmdapi.AuthInfo) bool {
+	x, y := *a, *b
+	x.LocationOfOrigin, y.LocationOfOrigin = "", ""
+	return reflect.DeepEqual(x, y)
+}
+
+func equalContexts(a, b *clientcmdapi.Context) bool {
+	x, y := *a, *b
+	x.LocationOfOrigin, y.LocationOfOrigin = "", ""
+	return reflect.DeepEqual(x, y)
+}
+
+func printImportResults(out io.Writer, results []importResult) error {
+	w := printers.GetNewTabWriter(out)
+	defer w.Flush()
+
+	if _, err := fmt.Fprintf(w, "%s\n", strings.Join([]string{"KIND", "NAME", "ACTION", "IMPORTED AS"}, "\t")); err != nil {
+		return err
+	}
+	for _, r := range results {
+		importedAs := r.importedAs
+		if len(importedAs) == 0 {
+			importedAs = "-"
+		}
+		if _, err := fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.kind, r.name, r.action, importedAs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
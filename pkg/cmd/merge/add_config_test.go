@@ -0,0 +1,197 @@
+package merge
+
+import (
+	"reflect"
+	"testing"
+
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// localConfig is the kubeconfig the entries are imported into.
+func localConfig() *clientcmdapi.Config {
+	config := clientcmdapi.NewConfig()
+	config.Clusters["prod-c"] = &clientcmdapi.Cluster{LocationOfOrigin: "/home/config", Server: "https://127.0.0.1:1"}
+	config.Clusters["dev-c"] = &clientcmdapi.Cluster{LocationOfOrigin: "/home/config", Server: "https://127.0.0.1:2"}
+	config.AuthInfos["admin"] = &clientcmdapi.AuthInfo{LocationOfOrigin: "/home/config", Token: "local"}
+	config.Contexts["prod"] = &clientcmdapi.Context{LocationOfOrigin: "/home/config", Cluster: "prod-c", AuthInfo: "admin"}
+	return config
+}
+
+// importedConfig has a conflicting prod-c cluster, admin user and prod context, an identical dev-c cluster,
+// and a lonely-u user only referenced by a context of the conflicting cluster.
+func importedConfig() *clientcmdapi.Config {
+	config := clientcmdapi.NewConfig()
+	config.Clusters["prod-c"] = &clientcmdapi.Cluster{Server: "https://10.0.0.1"}
+	config.Clusters["dev-c"] = &clientcmdapi.Cluster{Server: "https://127.0.0.1:2"}
+	config.Clusters["new-c"] = &clientcmdapi.Cluster{Server: "https://10.0.0.2"}
+	config.AuthInfos["admin"] = &clientcmdapi.AuthInfo{Token: "imported"}
+	config.AuthInfos["lonely-u"] = &clientcmdapi.AuthInfo{Token: "lonely"}
+	config.AuthInfos["new-u"] = &clientcmdapi.AuthInfo{Token: "new"}
+	config.Contexts["prod"] = &clientcmdapi.Context{Cluster: "prod-c", AuthInfo: "admin"}
+	config.Contexts["newctx"] = &clientcmdapi.Context{Cluster: "prod-c", AuthInfo: "lonely-u"}
+	config.Contexts["devctx"] = &clientcmdapi.Context{Cluster: "dev-c", AuthInfo: "new-u"}
+	config.Contexts["fresh"] = &clientcmdapi.Context{Cluster: "new-c", AuthInfo: "new-u"}
+	return config
+}
+
+func TestImportConfig(t *testing.T) {
+	tests := []struct {
+		strategy string
+		// clusters are the servers and the locations of origin of the resulting clusters.
+		clusters map[string][2]string
+		// authInfos are the tokens of the resulting authinfos.
+		authInfos map[string]string
+		// contexts are the cluster and the authinfo of the resulting contexts.
+		contexts map[string][2]string
+		// actions are the actions of the import report by kind and name.
+		actions map[string]string
+	}{
+		{
+			strategy: conflictSkip,
+			clusters: map[string][2]string{
+				"prod-c": {"https://127.0.0.1:1", "/home/config"},
+				"dev-c":  {"https://127.0.0.1:2", "/home/config"},
+				"new-c":  {"https://10.0.0.2", ""},
+			},
+			authInfos: map[string]string{"admin": "local", "new-u": "new"},
+			contexts: map[string][2]string{
+				"prod":   {"prod-c", "admin"},
+				"devctx": {"dev-c", "new-u"},
+				"fresh":  {"new-c", "new-u"},
+			},
+			actions: map[string]string{
+				"cluster/prod-c": actionSkipped, "cluster/dev-c": actionIdentical, "cluster/new-c": actionAdded,
+				"auth/admin": actionSkipped, "auth/lonely-u": actionSkipped, "auth/new-u": actionAdded,
+				"context/prod": actionSkipped, "context/newctx": actionSkipped, "context/devctx": actionAdded, "context/fresh": actionAdded,
+			},
+		},
+		{
+			strategy: conflictOverwrite,
+			clusters: map[string][2]string{
+				"prod-c": {"https://10.0.0.1", "/home/config"},
+				"dev-c":  {"https://127.0.0.1:2", "/home/config"},
+				"new-c":  {"https://10.0.0.2", ""},
+			},
+			authInfos: map[string]string{"admin": "imported", "lonely-u": "lonely", "new-u": "new"},
+			contexts: map[string][2]string{
+				"prod":   {"prod-c", "admin"},
+				"newctx": {"prod-c", "lonely-u"},
+				"devctx": {"dev-c", "new-u"},
+				"fresh":  {"new-c", "new-u"},
+			},
+			actions: map[string]string{
+				"cluster/prod-c": actionOverwritten, "cluster/dev-c": actionIdentical, "cluster/new-c": actionAdded,
+				"auth/admin": actionOverwritten, "auth/lonely-u": actionAdded, "auth/new-u": actionAdded,
+				"context/prod": actionIdentical, "context/newctx": actionAdded, "context/devctx": actionAdded, "context/fresh": actionAdded,
+			},
+		},
+		{
+			strategy: conflictRename,
+			clusters: map[string][2]string{
+				"prod-c":   {"https://127.0.0.1:1", "/home/config"},
+				"prod-c-1": {"https://10.0.0.1", ""},
+				"dev-c":    {"https://127.0.0.1:2", "/home/config"},
+				"new-c":    {"https://10.0.0.2", ""},
+			},
+			authInfos: map[string]string{"admin": "local", "admin-1": "imported", "lonely-u": "lonely", "new-u": "new"},
+			contexts: map[string][2]string{
+				"prod":   {"prod-c", "admin"},
+				"prod-1": {"prod-c-1", "admin-1"},
+				"newctx": {"prod-c-1", "lonely-u"},
+				"devctx": {"dev-c", "new-u"},
+				"fresh":  {"new-c", "new-u"},
+			},
+			actions: map[string]string{
+				"cluster/prod-c": actionRenamed, "cluster/dev-c": actionIdentical, "cluster/new-c": actionAdded,
+				"auth/admin": actionRenamed, "auth/lonely-u": actionAdded, "auth/new-u": actionAdded,
+				"context/prod": actionRenamed, "context/newctx": actionAdded, "context/devctx": actionAdded, "context/fresh": actionAdded,
+			},
+		},
+		{
+			strategy: conflictPrefix,
+			clusters: map[string][2]string{
+				"prod-c":     {"https://127.0.0.1:1", "/home/config"},
+				"imp-prod-c": {"https://10.0.0.1", ""},
+				"dev-c":      {"https://127.0.0.1:2", "/home/config"},
+				"new-c":      {"https://10.0.0.2", ""},
+			},
+			authInfos: map[string]string{"admin": "local", "imp-admin": "imported", "lonely-u": "lonely", "new-u": "new"},
+			contexts: map[string][2]string{
+				"prod":     {"prod-c", "admin"},
+				"imp-prod": {"imp-prod-c", "imp-admin"},
+				"newctx":   {"imp-prod-c", "lonely-u"},
+				"devctx":   {"dev-c", "new-u"},
+				"fresh":    {"new-c", "new-u"},
+			},
+			actions: map[string]string{
+				"cluster/prod-c": actionPrefixed, "cluster/dev-c": actionIdentical, "cluster/new-c": actionAdded,
+				"auth/admin": actionPrefixed, "auth/lonely-u": actionAdded, "auth/new-u": actionAdded,
+				"context/prod": actionPrefixed, "context/newctx": actionAdded, "context/devctx": actionAdded, "context/fresh": actionAdded,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		i := &importer{config: localConfig(), strategy: test.strategy, prefix: "imp-"}
+		i.importConfig(importedConfig())
+
+		clusters := map[string][2]string{}
+		for name, cluster := range i.config.Clusters {
+			clusters[name] = [2]string{cluster.Server, cluster.LocationOfOrigin}
+		}
+		if !reflect.DeepEqual(clusters, test.clusters) {
+			t.Errorf("%s: expected clusters %v, got %v", test.strategy, test.clusters, clusters)
+		}
+
+		authInfos := map[string]string{}
+		for name, authInfo := range i.config.AuthInfos {
+			authInfos[name] = authInfo.Token
+		}
+		if !reflect.DeepEqual(authInfos, test.authInfos) {
+			t.Errorf("%s: expected users %v, got %v", test.strategy, test.authInfos, authInfos)
+		}
+
+		contexts := map[string][2]string{}
+		for name, context := range i.config.Contexts {
+			contexts[name] = [2]string{context.Cluster, context.AuthInfo}
+		}
+		if !reflect.DeepEqual(contexts, test.contexts) {
+			t.Errorf("%s: expected contexts %v, got %v", test.strategy, test.contexts, contexts)
+		}
+
+		actions := map[string]string{}
+		for _, r := range i.results {
+			actions[r.kind+"/"+r.name] = r.action
+		}
+		if !reflect.DeepEqual(actions, test.actions) {
+			t.Errorf("%s: expected actions %v, got %v", test.strategy, test.actions, actions)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	taken := func(n string) bool { return n == "a" || n == "a-1" || n == "p-a" }
+	tests := []struct {
+		name      string
+		strategy  string
+		entry     string
+		identical bool
+		newName   string
+		action    string
+	}{
+		{name: "free name", strategy: conflictSkip, entry: "b", newName: "b", action: actionAdded},
+		{name: "identical entry", strategy: conflictRename, entry: "a", identical: true, newName: "a", action: actionIdentical},
+		{name: "skip", strategy: conflictSkip, entry: "a", newName: "a", action: actionSkipped},
+		{name: "overwrite", strategy: conflictOverwrite, entry: "a", newName: "a", action: actionOverwritten},
+		{name: "rename past a taken name-N", strategy: conflictRename, entry: "a", newName: "a-2", action: actionRenamed},
+		{name: "prefixed name taken", strategy: conflictPrefix, entry: "a", newName: "p-a-1", action: actionPrefixed},
+	}
+
+	for _, test := range tests {
+		i := &importer{strategy: test.strategy, prefix: "p-"}
+		newName, action := i.resolve("cluster", test.entry, test.identical, taken)
+		if newName != test.newName || action != test.action {
+			t.Errorf("%s: expected %q %s, got %q %s", test.name, test.newName, test.action, newName, action)
+		}
+	}
+}
@@ -0,0 +1,451 @@
+package merge
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+const (
+	formatEKS       = "eks"
+	formatGKE       = "gke"
+	formatAKS       = "aks"
+	formatTerraform = "terraform"
+
+	// execAPIVersion is the API version of the exec credential plugins of the provider users.
+	execAPIVersion = "client.authentication.k8s.io/v1beta1"
+	// aksServerID is the application id of the Azure Kubernetes Service AAD server, the same for every AAD enabled cluster.
+	aksServerID = "6dae42f8-4368-4678-94ff-3960e28e3630"
+)
+
+var validProviderFormats = sets.NewString(formatEKS, formatGKE, formatAKS, formatTerraform)
+
+// the terraform outputs searched for the cluster, in order of preference.
+var (
+	terraformKubeconfigOutputs = []string{"kubeconfig", "kube_config_raw", "kube_config"}
+	terraformEndpointOutputs   = []string{"cluster_endpoint", "endpoint", "host", "kube_host", "server"}
+	terraformCAOutputs         = []string{"cluster_certificate_authority_data", "cluster_ca_certificate", "ca_certificate", "certificate_authority_data"}
+	terraformNameOutputs       = []string{"cluster_name", "name"}
+	terraformARNOutputs        = []string{"cluster_arn", "arn"}
+	terraformTokenOutputs      = []string{"token", "cluster_token"}
+)
+
+var (
+	addFromProviderLong = templates.LongDesc(`
+		Builds a cluster, a user and a context from the JSON description of a managed cluster, without
+		calling the provider and without rewriting the rest of your kubeconfig file.
+
+		* eks: the output of 'aws eks describe-cluster', with an 'aws eks get-token' exec user.
+		* gke: the output of 'gcloud container clusters describe --format json', with a
+		  gke-gcloud-auth-plugin exec user.
+		* aks: the output of 'az aks show -o json' of an Azure AD enabled cluster, with a kubelogin exec
+		  user. It holds no certificate authority, which can be given with --certificate-authority.
+		* terraform: the output of 'terraform output -json'. A kubeconfig output is imported as is,
+		  otherwise the endpoint, certificate authority and token outputs are used, the user of an EKS or
+		  AKS endpoint being an exec user. The name of an EKS cluster is read from its name or ARN
+		  outputs, or given with --name.
+
+		The three entries are named like provider_location_cluster unless --name is given. Names which
+		are already used are handled according to --on-conflict.`)
+
+	addFromProviderExample = templates.Examples(`
+		# Add an EKS cluster
+		aws eks describe-cluster --name prod > prod.json
+		kubectl cfg add from-provider --format eks -f prod.json
+
+		# Add a GKE cluster under the name staging
+		gcloud container clusters describe staging --region europe-west1 --format json > staging.json
+		kubectl cfg add from-provider --format gke -f staging.json --name staging
+
+		# Add the cluster created by terraform
+		terraform output -json > outputs.json
+		kubectl cfg add from-provider --format terraform -f outputs.json`)
+)
+
+// AddFromProviderOptions contains the assignable options from the args.
+type AddFromProviderOptions struct {
+	ImportOptions
+	file                 string
+	format               string
+	name                 string
+	certificateAuthority string
+}
+
+// NewCmdCfgAddFromProvider returns a Command instance for 'cfg add from-provider' sub command
+func NewCmdCfgAddFromProvider(streams genericclioptions.IOStreams, configAccess clientcmd.ConfigAccess) *cobra.Command {
+	options := &AddFromProviderOptions{ImportOptions: newImportOptions(streams, configAccess)}
+
+	cmd := &cobra.Command{
+		Use:                   "from-provider --format=eks|gke|aks|terraform -f FILE [--name=NAME] [--certificate-authority=FILE] [--on-conflict=skip|overwrite|rename|prefix]",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Add a managed cluster from the JSON output of its provider CLI or of terraform"),
+		Long:                  addFromProviderLong,
+		Example:               addFromProviderExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(options.Complete(cmd, args))
+			cmdutil.CheckErr(options.Validate())
+			cmdutil.CheckErr(options.RunAddFromProvider())
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.file, "filename", "f", options.file, "JSON output to read, - to read it from the standard input")
+	cmd.Flags().StringVar(&options.format, "format", options.format, "Format of the JSON output. One of: eks|gke|aks|terraform")
+	cmd.Flags().StringVar(&options.name, "name", options.name, "Name of the cluster, user and context")
+	cmd.Flags().StringVar(&options.certificateAuthority, clientcmd.FlagCAFile, options.certificateAuthority, "Certificate authority file of the cluster, replacing the one of the JSON output")
+	cmd.MarkFlagFilename(clientcmd.FlagCAFile)
+	options.addImportFlags(cmd, "the format")
+	return cmd
+}
+
+// Complete assigns AddFromProviderOptions from the args.
+func (o *AddFromProviderOptions) Complete(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmdutil.UsageErrorf(cmd, "unexpected args: %v", args)
+	}
+	if len(o.file) == 0 {
+		return cmdutil.UsageErrorf(cmd, "a JSON output is required, use -f")
+	}
+	o.defaultPrefix(o.format)
+	return nil
+}
+
+// Validate checks the format and the conflict strategy.
+func (o *AddFromProviderOptions) Validate() error {
+	if !validProviderFormats.Has(o.format) {
+		return fmt.Errorf("--format must be one of %s: %q", strings.Join(validProviderFormats.List(), ", "), o.format)
+	}
+	return o.ImportOptions.Validate()
+}
+
+// RunAddFromProvider builds the entries of the JSON output and imports them into the kubeconfig.
+func (o *AddFromProviderOptions) RunAddFromProvider() error {
+	data, err := readFile(o.file, o.In)
+	if err != nil {
+		return err
+	}
+
+	var p *provided
+	switch o.format {
+	case formatEKS:
+		p, err = eksCluster(data)
+	case formatGKE:
+		p, err = gkeCluster(data)
+	case formatAKS:
+		p, err = aksCluster(data)
+	case formatTerraform:
+		p, err = terraformCluster(data, o.name, strings.TrimSuffix(filepath.Base(o.file), filepath.Ext(o.file)))
+	}
+	if err != nil {
+		return fmt.Errorf("error reading the %s output %s: %v", o.format, o.file, err)
+	}
+
+	if p.kubeconfig != nil {
+		return o.RunImport(p.kubeconfig, o.file)
+	}
+
+	if len(o.certificateAuthority) != 0 {
+		if p.certificateAuthority, err = ioutil.ReadFile(o.certificateAuthority); err != nil {
+			return err
+		}
+	}
+	if len(p.certificateAuthority) == 0 {
+		fmt.Fprintf(o.ErrOut, "warning: %s holds no certificate authority, the server certificate will be verified with the system roots, use --%s to set it\n", o.file, clientcmd.FlagCAFile)
+	}
+	if len(o.name) != 0 {
+		p.name = o.name
+	}
+	return o.RunImport(p.config(), o.file)
+}
+
+// provided is the cluster described by a provider output: either a whole kubeconfig, or the parts of
+// a cluster, a user and a context sharing the same name.
+type provided struct {
+	kubeconfig *clientcmdapi.Config
+
+	name                 string
+	server               string
+	certificateAuthority []byte
+	authInfo             *clientcmdapi.AuthInfo
+}
+
+func (p *provided) config() *clientcmdapi.Config {
+	config := clientcmdapi.NewConfig()
+
+	cluster := clientcmdapi.NewCluster()
+	cluster.Server = p.server
+	cluster.CertificateAuthorityData = p.certificateAuthority
+	config.Clusters[p.name] = cluster
+
+	config.AuthInfos[p.name] = p.authInfo
+
+	context := clientcmdapi.NewContext()
+	context.Cluster = p.name
+	context.AuthInfo = p.name
+	config.Contexts[p.name] = context
+
+	return config
+}
+
+func execAuthInfo(command string, args ...string) *clientcmdapi.AuthInfo {
+	authInfo := clientcmdapi.NewAuthInfo()
+	authInfo.Exec = &clientcmdapi.ExecConfig{Command: command, Args: args, APIVersion: execAPIVersion}
+	return authInfo
+}
+
+func eksAuthInfo(region, name string) *clientcmdapi.AuthInfo {
+	return execAuthInfo("aws", "--region", region, "eks", "get-token", "--cluster-name", name)
+}
+
+func aksAuthInfo() *clientcmdapi.AuthInfo {
+	return execAuthInfo("kubelogin", "get-token", "--login", "azurecli", "--server-id", aksServerID)
+}
+
+// decodeCertificateAuthority decodes a base64 certificate authority, a PEM one being kept as is.
+func decodeCertificateAuthority(data string) ([]byte, error) {
+	if strings.HasPrefix(strings.TrimSpace(data), "-----BEGIN") {
+		return []byte(data), nil
+	}
+	return base64.StdEncoding.DecodeString(data)
+}
+
+// httpsServer adds the https scheme to an endpoint given as a bare host.
+func httpsServer(endpoint string) string {
+	if len(endpoint) == 0 || strings.Contains(endpoint, "://") {
+		return endpoint
+	}
+	return "https://" + endpoint
+}
+
+type eksOutput struct {
+	Cluster struct {
+		Name                 string `json:"name"`
+		Arn                  string `json:"arn"`
+		Endpoint             string `json:"endpoint"`
+		CertificateAuthority struct {
+			Data string `json:"data"`
+		} `json:"certificateAuthority"`
+	} `json:"cluster"`
+}
+
+func eksCluster(data []byte) (*provided, error) {
+	output := &eksOutput{}
+	if err := json.Unmarshal(data, output); err != nil {
+		return nil, err
+	}
+	cluster := output.Cluster
+	if len(cluster.Name) == 0 || len(cluster.Endpoint) == 0 {
+		return nil, fmt.Errorf("cluster.name and cluster.endpoint are required")
+	}
+
+	// arn:aws:eks:REGION:ACCOUNT:cluster/NAME
+	region := eksEndpointRegion(cluster.Endpoint)
+	if parts := strings.Split(cluster.Arn, ":"); len(parts) > 3 {
+		region = parts[3]
+	}
+	if len(region) == 0 {
+		return nil, fmt.Errorf("the region of cluster %s is found neither in its arn nor in its endpoint", cluster.Name)
+	}
+
+	ca, err := decodeCertificateAuthority(cluster.CertificateAuthority.Data)
+	if err != nil {
+		return nil, fmt.Errorf("cluster.certificateAuthority.data: %v", err)
+	}
+	return &provided{
+		name:                 fmt.Sprintf("eks_%s_%s", region, cluster.Name),
+		server:               cluster.Endpoint,
+		certificateAuthority: ca,
+		authInfo:             eksAuthInfo(region, cluster.Name),
+	}, nil
+}
+
+// eksARNName returns the name of a cluster of an ARN like arn:aws:eks:REGION:ACCOUNT:cluster/NAME, or "".
+func eksARNName(arn string) string {
+	parts := strings.Split(arn, ":")
+	if len(parts) != 6 || parts[2] != "eks" || !strings.HasPrefix(parts[5], "cluster/") {
+		return ""
+	}
+	return strings.TrimPrefix(parts[5], "cluster/")
+}
+
+// eksEndpointRegion returns the region of an endpoint like https://ID.gr7.REGION.eks.amazonaws.com, or "".
+func eksEndpointRegion(endpoint string) string {
+	u, err := url.Parse(httpsServer(endpoint))
+	if err != nil {
+		return ""
+	}
+	labels := strings.Split(u.Hostname(), ".")
+	for ix := len(labels) - 1; ix > 0; ix-- {
+		if labels[ix] == "eks" && ix+1 < len(labels) && labels[ix+1] == "amazonaws" {
+			return labels[ix-1]
+		}
+	}
+	return ""
+}
+
+type gkeOutput struct {
+	Name       string `json:"name"`
+	Endpoint   string `json:"endpoint"`
+	Location   string `json:"location"`
+	Zone       string `json:"zone"`
+	SelfLink   string `json:"selfLink"`
+	MasterAuth struct {
+		ClusterCaCertificate string `json:"clusterCaCertificate"`
+	} `json:"masterAuth"`
+}
+
+func gkeCluster(data []byte) (*provided, error) {
+	output := &gkeOutput{}
+	if err := json.Unmarshal(data, output); err != nil {
+		return nil, err
+	}
+	if len(output.Name) == 0 || len(output.Endpoint) == 0 {
+		return nil, fmt.Errorf("name and endpoint are required")
+	}
+
+	location := output.Location
+	if len(location) == 0 {
+		location = output.Zone
+	}
+	// https://container.googleapis.com/v1/projects/PROJECT/locations/LOCATION/clusters/NAME
+	project := ""
+	parts := strings.Split(output.SelfLink, "/")
+	for ix := 0; ix+1 < len(parts); ix++ {
+		if parts[ix] == "projects" {
+			project = parts[ix+1]
+		}
+	}
+
+	ca, err := decodeCertificateAuthority(output.MasterAuth.ClusterCaCertificate)
+	if err != nil {
+		return nil, fmt.Errorf("masterAuth.clusterCaCertificate: %v", err)
+	}
+	return &provided{
+		// the name gcloud gives to its contexts
+		name:                 fmt.Sprintf("gke_%s_%s_%s", project, location, output.Name),
+		server:               httpsServer(output.Endpoint),
+		certificateAuthority: ca,
+		authInfo:             execAuthInfo("gke-gcloud-auth-plugin"),
+	}, nil
+}
+
+type aksOutput struct {
+	Name          string `json:"name"`
+	ResourceGroup string `json:"resourceGroup"`
+	Fqdn          string `json:"fqdn"`
+	PrivateFqdn   string `json:"privateFqdn"`
+	AadProfile    *struct {
+		TenantID string `json:"tenantId"`
+	} `json:"aadProfile"`
+}
+
+func aksCluster(data []byte) (*provided, error) {
+	output := &aksOutput{}
+	if err := json.Unmarshal(data, output); err != nil {
+		return nil, err
+	}
+	fqdn := output.Fqdn
+	if len(fqdn) == 0 {
+		fqdn = output.PrivateFqdn
+	}
+	if len(output.Name) == 0 || len(fqdn) == 0 {
+		return nil, fmt.Errorf("name and fqdn are required")
+	}
+	if output.AadProfile == nil {
+		return nil, fmt.Errorf("cluster %s is not Azure AD enabled, its credentials are only given by 'az aks get-credentials'", output.Name)
+	}
+
+	return &provided{
+		name:     fmt.Sprintf("aks_%s_%s", output.ResourceGroup, output.Name),
+		server:   httpsServer(fqdn + ":443"),
+		authInfo: aksAuthInfo(),
+	}, nil
+}
+
+// terraformOutput is an output of 'terraform output -json'.
+type terraformOutput struct {
+	Value json.RawMessage `json:"value"`
+}
+
+// terraformCluster builds the cluster of the outputs of 'terraform output -json', named after its name
+// or ARN outputs, otherwise after name. The name of an EKS cluster is given to 'aws eks get-token' and
+// is never guessed, only the entries of another cluster are named after defaultName at last.
+func terraformCluster(data []byte, name, defaultName string) (*provided, error) {
+	outputs := map[string]terraformOutput{}
+	if err := json.Unmarshal(data, &outputs); err != nil {
+		return nil, err
+	}
+
+	if kubeconfig, ok := terraformString(outputs, terraformKubeconfigOutputs); ok {
+		config, err := clientcmd.Load([]byte(kubeconfig))
+		if err != nil {
+			return nil, fmt.Errorf("kubeconfig output: %v", err)
+		}
+		return &provided{kubeconfig: config}, nil
+	}
+
+	endpoint, ok := terraformString(outputs, terraformEndpointOutputs)
+	if !ok {
+		return nil, fmt.Errorf("none of the outputs %s is found", strings.Join(append(terraformKubeconfigOutputs, terraformEndpointOutputs...), ", "))
+	}
+	clusterName, ok := terraformString(outputs, terraformNameOutputs)
+	if !ok {
+		arn, _ := terraformString(outputs, terraformARNOutputs)
+		clusterName = eksARNName(arn)
+	}
+	if len(clusterName) == 0 {
+		clusterName = name
+	}
+	p := &provided{name: clusterName, server: httpsServer(endpoint)}
+	if len(p.name) == 0 {
+		p.name = defaultName
+	}
+	if ca, ok := terraformString(outputs, terraformCAOutputs); ok {
+		var err error
+		if p.certificateAuthority, err = decodeCertificateAuthority(ca); err != nil {
+			return nil, fmt.Errorf("certificate authority output: %v", err)
+		}
+	}
+
+	if token, ok := terraformString(outputs, terraformTokenOutputs); ok {
+		p.authInfo = clientcmdapi.NewAuthInfo()
+		p.authInfo.Token = token
+	} else if region := eksEndpointRegion(endpoint); len(region) != 0 {
+		if len(clusterName) == 0 {
+			return nil, fmt.Errorf("the name of the EKS cluster %s is found in none of the outputs %s, use --name to give it", endpoint, strings.Join(append(terraformNameOutputs, terraformARNOutputs...), ", "))
+		}
+		p.authInfo = eksAuthInfo(region, clusterName)
+	} else if strings.Contains(endpoint, ".azmk8s.io") {
+		p.authInfo = aksAuthInfo()
+	} else {
+		return nil, fmt.Errorf("the credentials of %s are found neither in the outputs %s nor from an EKS or AKS endpoint", endpoint, strings.Join(terraformTokenOutputs, ", "))
+	}
+	return p, nil
+}
+
+// terraformString returns the first of the named outputs which is a non empty string.
+func terraformString(outputs map[string]terraformOutput, names []string) (string, bool) {
+	for _, name := range names {
+		output, ok := outputs[name]
+		if !ok {
+			continue
+		}
+		value := ""
+		if err := json.Unmarshal(output.Value, &value); err == nil && len(value) != 0 {
+			return value, true
+		}
+	}
+	return "", false
+}
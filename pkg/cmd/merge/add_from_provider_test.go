@@ -0,0 +1,69 @@
+package merge
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTerraformCluster(t *testing.T) {
+	tests := []struct {
+		name         string
+		outputs      string
+		flagName     string
+		expectedName string
+		expectedArgs string
+		expectedErr  string
+	}{
+		{
+			name:         "eks name output",
+			outputs:      `{"cluster_endpoint": {"value": "https://ABC.gr7.eu-west-1.eks.amazonaws.com"}, "cluster_name": {"value": "prod"}}`,
+			flagName:     "other",
+			expectedName: "prod",
+			expectedArgs: "--region eu-west-1 eks get-token --cluster-name prod",
+		},
+		{
+			name:         "eks arn output",
+			outputs:      `{"cluster_endpoint": {"value": "https://ABC.gr7.eu-west-1.eks.amazonaws.com"}, "cluster_arn": {"value": "arn:aws:eks:eu-west-1:123456789012:cluster/prod"}}`,
+			expectedName: "prod",
+			expectedArgs: "--region eu-west-1 eks get-token --cluster-name prod",
+		},
+		{
+			name:         "eks name flag",
+			outputs:      `{"cluster_endpoint": {"value": "https://ABC.gr7.eu-west-1.eks.amazonaws.com"}}`,
+			flagName:     "prod",
+			expectedName: "prod",
+			expectedArgs: "--region eu-west-1 eks get-token --cluster-name prod",
+		},
+		{
+			name:        "eks without name",
+			outputs:     `{"cluster_endpoint": {"value": "https://ABC.gr7.eu-west-1.eks.amazonaws.com"}, "arn": {"value": "arn:aws:iam::123456789012:role/admin"}}`,
+			expectedErr: "use --name",
+		},
+		{
+			name:         "token without name",
+			outputs:      `{"host": {"value": "10.0.0.1:6443"}, "token": {"value": "secret"}}`,
+			expectedName: "outputs",
+		},
+	}
+	for _, test := range tests {
+		p, err := terraformCluster([]byte(test.outputs), test.flagName, "outputs")
+		if len(test.expectedErr) != 0 {
+			if err == nil || !strings.Contains(err.Error(), test.expectedErr) {
+				t.Errorf("%s: expected an error containing %q, got %v", test.name, test.expectedErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error %v", test.name, err)
+			continue
+		}
+		if p.name != test.expectedName {
+			t.Errorf("%s: expected the name %q, got %q", test.name, test.expectedName, p.name)
+		}
+		if len(test.expectedArgs) != 0 {
+			if p.authInfo.Exec == nil || strings.Join(p.authInfo.Exec.Args, " ") != test.expectedArgs {
+				t.Errorf("%s: expected the exec args %q, got %v", test.name, test.expectedArgs, p.authInfo.Exec)
+			}
+		}
+	}
+}
@@ -0,0 +1,359 @@
+package merge
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+// the flavors of local clusters.
+const (
+	localK3s      = "k3s"
+	localKubeadm  = "kubeadm"
+	localMinikube = "minikube"
+	localK3d      = "k3d"
+	localKind     = "kind"
+	localOther    = "local"
+)
+
+const (
+	k3sKubeconfig     = "/etc/rancher/k3s/k3s.yaml"
+	kubeadmKubeconfig = "/etc/kubernetes/admin.conf"
+	envMinikubeHome   = "MINIKUBE_HOME"
+)
+
+// genericNames are the entry names written by the local cluster tools, which collide from one cluster to the other.
+var genericNames = map[string]bool{
+	"default":                     true,
+	"kubernetes":                  true,
+	"kubernetes-admin":            true,
+	"kubernetes-admin@kubernetes": true,
+}
+
+// loopbackHosts are the server hosts which only make sense on the machine of the cluster.
+var loopbackHosts = map[string]bool{
+	"127.0.0.1": true,
+	"localhost": true,
+	"0.0.0.0":   true,
+	"::1":       true,
+}
+
+var (
+	addLocalLong = templates.LongDesc(`
+		Imports the kubeconfig files of local development clusters.
+
+		Without FILE, the well-known sources are discovered: the k3s config /etc/rancher/k3s/k3s.yaml,
+		the kubeadm config /etc/kubernetes/admin.conf, the minikube profiles and the k3d configs
+		written into ~/.k3d. Files exported by 'kind export kubeconfig' or 'k3d kubeconfig write' can
+		be given as FILE.
+
+		The generic default, kubernetes, kubernetes-admin and kubernetes-admin@kubernetes names are
+		renamed after the flavor of the cluster, or --name, followed by --host when given. --host also
+		replaces the 127.0.0.1 and localhost servers, for the clusters running in a VM or on another
+		machine; the certificate of the server must be valid for that host.
+
+		Names which are still used are renamed by default, see --on-conflict.`)
+
+	addLocalExample = templates.Examples(`
+		# Import every local cluster found on this machine
+		kubectl cfg add local
+
+		# Import a kind cluster
+		kind export kubeconfig --name dev --kubeconfig ./kind-dev.yaml
+		kubectl cfg add local ./kind-dev.yaml
+
+		# Import the k3s config copied from a VM, as the k3s-192.168.64.5 entries
+		kubectl cfg add local ./k3s.yaml --host=192.168.64.5`)
+)
+
+// AddLocalOptions contains the assignable options from the args.
+type AddLocalOptions struct {
+	ImportOptions
+	files []string
+	name  string
+	host  string
+}
+
+// localSource is a kubeconfig of a local cluster.
+type localSource struct {
+	flavor string
+	path   string
+	load   func() (*clientcmdapi.Config, error)
+}
+
+// NewCmdCfgAddLocal returns a Command instance for 'cfg add local' sub command
+func NewCmdCfgAddLocal(streams genericclioptions.IOStreams, configAccess clientcmd.ConfigAccess) *cobra.Command {
+	options := &AddLocalOptions{ImportOptions: newImportOptions(streams, configAccess)}
+	options.onConflict = conflictRename
+
+	cmd := &cobra.Command{
+		Use:                   "local [FILE...] [--name=NAME] [--host=HOST] [--on-conflict=skip|overwrite|rename|prefix]",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Import the kubeconfig files of k3s, kubeadm, minikube, k3d and kind clusters"),
+		Long:                  addLocalLong,
+		Example:               addLocalExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(options.Complete(cmd, args))
+			cmdutil.CheckErr(options.Validate())
+			cmdutil.CheckErr(options.RunAddLocal())
+		},
+	}
+
+	cmd.Flags().StringVar(&options.name, "name", options.name, "Name replacing the generic names, defaults to the flavor of the cluster")
+	cmd.Flags().StringVar(&options.host, "host", options.host, "Host replacing 127.0.0.1 and localhost in the servers")
+	options.addImportFlags(cmd, "the flavor of the cluster")
+	return cmd
+}
+
+// Complete assigns AddLocalOptions from the args.
+func (o *AddLocalOptions) Complete(cmd *cobra.Command, args []string) error {
+	o.files = args
+	return nil
+}
+
+// RunAddLocal imports every local source, one after the other.
+func (o *AddLocalOptions) RunAddLocal() error {
+	sources := []localSource{}
+	for _, file := range o.files {
+		sources = append(sources, fileSource(file))
+	}
+	if len(o.files) == 0 {
+		sources = discoverLocalSources()
+		if len(sources) == 0 {
+			fmt.Fprintln(o.Out, "no local cluster found")
+			return nil
+		}
+	}
+
+	for _, source := range sources {
+		fmt.Fprintf(o.Out, "%s: %s\n", source.flavor, source.path)
+		config, err := source.load()
+		if err != nil {
+			if os.IsPermission(err) {
+				fmt.Fprintf(o.ErrOut, "warning: %s is not readable, run again with sudo or copy it: %v\n", source.path, err)
+				continue
+			}
+			return err
+		}
+
+		name := o.name
+		if len(name) == 0 {
+			name = source.flavor
+		}
+		if len(o.host) != 0 {
+			name = name + "-" + o.host
+			rewriteLoopbackServers(config, o.host)
+		}
+		renameGenericEntries(config, name)
+
+		options := o.ImportOptions
+		options.defaultPrefix(source.flavor)
+		if err := options.RunImport(config, source.path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// discoverLocalSources returns the kubeconfig files of the local clusters found on this machine.
+func discoverLocalSources() []localSource {
+	sources := []localSource{}
+	for _, file := range []string{k3sKubeconfig, kubeadmKubeconfig} {
+		if _, err := os.Stat(file); err == nil {
+			sources = append(sources, fileSource(file))
+		}
+	}
+
+	home, _ := os.UserHomeDir()
+	if len(home) == 0 {
+		return sources
+	}
+
+	minikubeHome := os.Getenv(envMinikubeHome)
+	if len(minikubeHome) == 0 {
+		minikubeHome = filepath.Join(home, ".minikube")
+	}
+	profiles, _ := filepath.Glob(filepath.Join(minikubeHome, "profiles", "*", "config.json"))
+	sort.Strings(profiles)
+	for _, profile := range profiles {
+		profile := profile
+		sources = append(sources, localSource{
+			flavor: localMinikube,
+			path:   profile,
+			load:   func() (*clientcmdapi.Config, error) { return minikubeConfig(minikubeHome, profile) },
+		})
+	}
+
+	k3dConfigs, _ := filepath.Glob(filepath.Join(home, ".k3d", "kubeconfig-*.yaml"))
+	legacyK3dConfigs, _ := filepath.Glob(filepath.Join(home, ".config", "k3d", "*", "kubeconfig.yaml"))
+	k3dConfigs = append(k3dConfigs, legacyK3dConfigs...)
+	sort.Strings(k3dConfigs)
+	for _, file := range k3dConfigs {
+		sources = append(sources, fileSource(file))
+		sources[len(sources)-1].flavor = localK3d
+	}
+	return sources
+}
+
+// fileSource reads a kubeconfig file, its flavor being told by its path and its names.
+func fileSource(file string) localSource {
+	source := localSource{flavor: localOther, path: file}
+	source.load = func() (*clientcmdapi.Config, error) {
+		config, err := clientcmd.LoadFromFile(file)
+		if err != nil {
+			return nil, err
+		}
+		return config, clientcmd.ResolveLocalPaths(config)
+	}
+
+	switch {
+	case file == k3sKubeconfig:
+		source.flavor = localK3s
+	case file == kubeadmKubeconfig:
+		source.flavor = localKubeadm
+	default:
+		if config, err := clientcmd.LoadFromFile(file); err == nil {
+			source.flavor = guessFlavor(config)
+		}
+	}
+	return source
+}
+
+// guessFlavor tells the tool which wrote a kubeconfig from the names of its contexts.
+func guessFlavor(config *clientcmdapi.Config) string {
+	for name := range config.Contexts {
+		switch {
+		case strings.HasPrefix(name, "kind-"):
+			return localKind
+		case strings.HasPrefix(name, "k3d-"):
+			return localK3d
+		case name == "kubernetes-admin@kubernetes":
+			return localKubeadm
+		case name == "default":
+			return localK3s
+		}
+	}
+	return localOther
+}
+
+// minikubeProfile is the part of a minikube profile config.json needed to reach the cluster.
+type minikubeProfile struct {
+	Name  string `json:"Name"`
+	Nodes []struct {
+		IP           string `json:"IP"`
+		Port         int    `json:"Port"`
+		ControlPlane bool   `json:"ControlPlane"`
+	} `json:"Nodes"`
+}
+
+// minikubeConfig builds the entries minikube writes for a profile, from the profile files.
+func minikubeConfig(minikubeHome, file string) (*clientcmdapi.Config, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	profile := &minikubeProfile{}
+	if err := json.Unmarshal(data, profile); err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", file, err)
+	}
+	if len(profile.Name) == 0 {
+		profile.Name = filepath.Base(filepath.Dir(file))
+	}
+
+	server := ""
+	for _, node := range profile.Nodes {
+		if node.ControlPlane || len(server) == 0 {
+			server = "https://" + net.JoinHostPort(node.IP, strconv.Itoa(node.Port))
+		}
+	}
+	if len(server) == 0 {
+		return nil, fmt.Errorf("profile %s has no node, is it started?", profile.Name)
+	}
+
+	profileDir := filepath.Dir(file)
+	config := clientcmdapi.NewConfig()
+
+	cluster := clientcmdapi.NewCluster()
+	cluster.Server = server
+	cluster.CertificateAuthority = filepath.Join(minikubeHome, "ca.crt")
+	config.Clusters[profile.Name] = cluster
+
+	authInfo := clientcmdapi.NewAuthInfo()
+	authInfo.ClientCertificate = filepath.Join(profileDir, "client.crt")
+	authInfo.ClientKey = filepath.Join(profileDir, "client.key")
+	config.AuthInfos[profile.Name] = authInfo
+
+	context := clientcmdapi.NewContext()
+	context.Cluster = profile.Name
+	context.AuthInfo = profile.Name
+	context.Namespace = "default"
+	config.Contexts[profile.Name] = context
+
+	return config, nil
+}
+
+// renameGenericEntries renames the entries with a generic name, and the references to them.
+func renameGenericEntries(config *clientcmdapi.Config, name string) {
+	for oldName, cluster := range config.Clusters {
+		if genericNames[oldName] {
+			delete(config.Clusters, oldName)
+			config.Clusters[name] = cluster
+			for _, context := range config.Contexts {
+				if context.Cluster == oldName {
+					context.Cluster = name
+				}
+			}
+		}
+	}
+	for oldName, authInfo := range config.AuthInfos {
+		if genericNames[oldName] {
+			delete(config.AuthInfos, oldName)
+			config.AuthInfos[name] = authInfo
+			for _, context := range config.Contexts {
+				if context.AuthInfo == oldName {
+					context.AuthInfo = name
+				}
+			}
+		}
+	}
+	for oldName, context := range config.Contexts {
+		if genericNames[oldName] {
+			delete(config.Contexts, oldName)
+			config.Contexts[name] = context
+			if config.CurrentContext == oldName {
+				config.CurrentContext = name
+			}
+		}
+	}
+}
+
+// rewriteLoopbackServers replaces the loopback host of the servers with host, keeping their port.
+func rewriteLoopbackServers(config *clientcmdapi.Config, host string) {
+	for _, cluster := range config.Clusters {
+		u, err := url.Parse(cluster.Server)
+		if err != nil || !loopbackHosts[u.Hostname()] {
+			continue
+		}
+		if port := u.Port(); len(port) != 0 {
+			u.Host = net.JoinHostPort(host, port)
+		} else {
+			u.Host = host
+		}
+		cluster.Server = u.String()
+	}
+}
@@ -0,0 +1,203 @@
+package merge
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	"github.com/spf13/cobra"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+const (
+	// clusterAPISecretType is the type of the <cluster>-kubeconfig secrets written by Cluster API.
+	clusterAPISecretType corev1.SecretType = "cluster.x-k8s.io/secret"
+	// clusterAPIKubeconfigKey is the key of the kubeconfig in a Cluster API secret.
+	clusterAPIKubeconfigKey = "value"
+	clusterAPISecretSuffix  = "-kubeconfig"
+)
+
+var (
+	addSecretLong = templates.LongDesc(`
+		Builds kubeconfig entries from a Secret manifest, without connecting to the cluster.
+
+		* A kubernetes.io/service-account-token secret gives an authinfo with its token, a cluster with
+		  --server and its ca.crt, and a context using both in the namespace of the secret. The three
+		  entries are named after the namespace and the service account unless --name is given.
+		* A Cluster API <cluster>-kubeconfig secret holds a whole kubeconfig, whose entries are imported
+		  like 'kubectl cfg add config' does. --server replaces the server of its clusters.
+
+		Names which are already used are handled according to --on-conflict.`)
+
+	addSecretExample = templates.Examples(`
+		# Add the cluster, authinfo and context of a service account token secret
+		kubectl cfg add secret -f ci-token.yaml --server=https://1.2.3.4:6443
+
+		# Import the kubeconfig of a Cluster API workload cluster
+		kubectl get secret prod-kubeconfig -o yaml > prod-kubeconfig.yaml
+		kubectl cfg add secret -f prod-kubeconfig.yaml`)
+)
+
+// AddSecretOptions contains the assignable options from the args.
+type AddSecretOptions struct {
+	ImportOptions
+	file   string
+	server string
+	name   string
+}
+
+// NewCmdCfgAddSecret returns a Command instance for 'cfg add secret' sub command
+func NewCmdCfgAddSecret(streams genericclioptions.IOStreams, configAccess clientcmd.ConfigAccess) *cobra.Command {
+	options := &AddSecretOptions{ImportOptions: newImportOptions(streams, configAccess)}
+
+	cmd := &cobra.Command{
+		Use:                   "secret -f FILE [--server=URL] [--name=NAME] [--on-conflict=skip|overwrite|rename|prefix] [--prefix=PREFIX]",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Add the entries of a service account token or Cluster API secret into kubeconfig"),
+		Long:                  addSecretLong,
+		Example:               addSecretExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(options.Complete(cmd, args))
+			cmdutil.CheckErr(options.Validate())
+			cmdutil.CheckErr(options.RunAddSecret())
+		},
+	}
+
+	cmd.Flags().StringVarP(&options.file, "filename", "f", options.file, "Secret manifest to read, as YAML or JSON, - to read it from the standard input")
+	cmd.Flags().StringVar(&options.server, clientcmd.FlagAPIServer, options.server, "Server of the cluster, required for a service account token secret")
+	cmd.Flags().StringVar(&options.name, "name", options.name, "Name of the cluster, authinfo and context built from a service account token secret")
+	options.addImportFlags(cmd, "the secret name without its -kubeconfig suffix")
+	return cmd
+}
+
+// Complete assigns AddSecretOptions from the args.
+func (o *AddSecretOptions) Complete(cmd *cobra.Command, args []string) error {
+	if len(args) != 0 {
+		return cmdutil.UsageErrorf(cmd, "unexpected args: %v", args)
+	}
+	if len(o.file) == 0 {
+		return cmdutil.UsageErrorf(cmd, "a secret manifest is required, use -f")
+	}
+	return nil
+}
+
+// RunAddSecret builds the entries of the secret and imports them into the kubeconfig.
+func (o *AddSecretOptions) RunAddSecret() error {
+	secret, err := readSecret(o.file, o.In)
+	if err != nil {
+		return err
+	}
+	o.defaultPrefix(strings.TrimSuffix(secret.Name, clusterAPISecretSuffix))
+
+	var imported *clientcmdapi.Config
+	switch {
+	case secret.Type == corev1.SecretTypeServiceAccountToken:
+		imported, err = serviceAccountConfig(secret, o.server, o.name)
+	case isClusterAPISecret(secret):
+		imported, err = clusterAPIConfig(secret, o.server)
+	default:
+		err = fmt.Errorf("secret %s is neither a %s nor a Cluster API kubeconfig secret: %s", secret.Name, corev1.SecretTypeServiceAccountToken, secret.Type)
+	}
+	if err != nil {
+		return err
+	}
+
+	return o.RunImport(imported, fmt.Sprintf("secret %s/%s", secret.Namespace, secret.Name))
+}
+
+// readSecret reads a Secret manifest, the values of its stringData taking precedence over its data like the API server does.
+func readSecret(file string, in io.Reader) (*corev1.Secret, error) {
+	data, err := readFile(file, in)
+	if err != nil {
+		return nil, err
+	}
+
+	secret := &corev1.Secret{}
+	if err := yaml.Unmarshal(data, secret); err != nil {
+		return nil, fmt.Errorf("error reading %s: %v", file, err)
+	}
+	if secret.Kind != "Secret" {
+		return nil, fmt.Errorf("%s is not a Secret manifest: kind %q", file, secret.Kind)
+	}
+
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	for key, value := range secret.StringData {
+		secret.Data[key] = []byte(value)
+	}
+	return secret, nil
+}
+
+// serviceAccountConfig builds a cluster, an authinfo and a context from a service account token secret.
+func serviceAccountConfig(secret *corev1.Secret, server, name string) (*clientcmdapi.Config, error) {
+	if len(server) == 0 {
+		return nil, fmt.Errorf("--%s is required for the service account token secret %s", clientcmd.FlagAPIServer, secret.Name)
+	}
+	token := secret.Data[corev1.ServiceAccountTokenKey]
+	if len(token) == 0 {
+		return nil, fmt.Errorf("secret %s has no %s", secret.Name, corev1.ServiceAccountTokenKey)
+	}
+
+	namespace := string(secret.Data[corev1.ServiceAccountNamespaceKey])
+	if len(namespace) == 0 {
+		namespace = secret.Namespace
+	}
+	if len(name) == 0 {
+		serviceAccount := secret.Annotations[corev1.ServiceAccountNameKey]
+		if len(serviceAccount) == 0 {
+			serviceAccount = secret.Name
+		}
+		name = serviceAccount
+		if len(namespace) != 0 {
+			name = namespace + "-" + serviceAccount
+		}
+	}
+
+	config := clientcmdapi.NewConfig()
+
+	cluster := clientcmdapi.NewCluster()
+	cluster.Server = server
+	cluster.CertificateAuthorityData = secret.Data[corev1.ServiceAccountRootCAKey]
+	config.Clusters[name] = cluster
+
+	authInfo := clientcmdapi.NewAuthInfo()
+	authInfo.Token = string(token)
+	config.AuthInfos[name] = authInfo
+
+	context := clientcmdapi.NewContext()
+	context.Cluster = name
+	context.AuthInfo = name
+	context.Namespace = namespace
+	config.Contexts[name] = context
+
+	return config, nil
+}
+
+func isClusterAPISecret(secret *corev1.Secret) bool {
+	if _, ok := secret.Data[clusterAPIKubeconfigKey]; !ok {
+		return false
+	}
+	return secret.Type == clusterAPISecretType || strings.HasSuffix(secret.Name, clusterAPISecretSuffix)
+}
+
+// clusterAPIConfig decodes the kubeconfig of a Cluster API secret, server replacing the server of its clusters when given.
+func clusterAPIConfig(secret *corev1.Secret, server string) (*clientcmdapi.Config, error) {
+	config, err := clientcmd.Load(secret.Data[clusterAPIKubeconfigKey])
+	if err != nil {
+		return nil, fmt.Errorf("error reading the kubeconfig of secret %s: %v", secret.Name, err)
+	}
+	if len(server) != 0 {
+		for _, cluster := range config.Clusters {
+			cluster.Server = server
+		}
+	}
+	return config, nil
+}
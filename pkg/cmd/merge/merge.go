@@ -1,6 +1,7 @@
 package merge
 
 import (
+	"github.com/it2911/kubectl-cfg/pkg/util/kubeconfig"
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/tools/clientcmd"
@@ -13,7 +14,7 @@ var (
 
 	listExample = templates.Examples(`
 		# Merge the kubeconfig into the output kubeconfig file
-		kubectl cfg merge config -f import-kubeconfig01.yaml -f import-kubeconfig02.yaml > export-kubeconfig.yaml`)
+		kubectl cfg merge config -f import-kubeconfig01.yaml -f import-kubeconfig02.yaml --show-secrets > export-kubeconfig.yaml`)
 )
 
 func NewCmdCfgMerge(streams genericclioptions.IOStreams, configAccess clientcmd.ConfigAccess) *cobra.Command {
@@ -25,6 +26,7 @@ func NewCmdCfgMerge(streams genericclioptions.IOStreams, configAccess clientcmd.
 		Long:                  listLong,
 		Example:               listExample,
 		Run:                   cmdutil.DefaultSubCommandRun(streams.ErrOut),
+		Annotations:           map[string]string{kubeconfig.ReadOnlyAnnotation: "true"},
 	}
 
 	cmd.Flags().StringP("output", "o", "", "Output format. One of: name")
@@ -3,37 +3,49 @@ package merge
 import (
 	"errors"
 	"fmt"
-	"os"
-	"strings"
+	"io"
 
+	"github.com/it2911/kubectl-cfg/pkg/util/kubeconfig"
+	"github.com/it2911/kubectl-cfg/pkg/util/redact"
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/tools/clientcmd/api/latest"
 	kconf "k8s.io/kubectl/pkg/cmd/config"
 	cmdutil "k8s.io/kubectl/pkg/cmd/util"
 	"k8s.io/kubectl/pkg/scheme"
 	"k8s.io/kubectl/pkg/util/i18n"
 	"k8s.io/kubectl/pkg/util/templates"
+	"k8s.io/kubectl/pkg/util/term"
 )
 
 const kubeconfigFlag string = "file"
 
 var (
-	addConfigLong = templates.LongDesc(`Merge multi the kubeconfig files.`)
+	addConfigLong = templates.LongDesc(`
+		Merge multi the kubeconfig files.
+
+		A file given as - is read from the standard input, and a file holding a base64 encoded
+		kubeconfig is decoded. --from-base64 merges kubeconfigs given as base64 strings after the files.
+		The first kubeconfig to define an entry or the current context wins, like with KUBECONFIG.`)
 
 	exampleString = `
     # Merge the kubeconfig into the output kubeconfig file
-	kubectl cfg merge config -f import-kubeconfig01.yaml -f import-kubeconfig02.yaml > export-kubeconfig.yaml`
+	kubectl cfg merge config -f import-kubeconfig01.yaml -f import-kubeconfig02.yaml --show-secrets > export-kubeconfig.yaml
+
+	# Review the merged kubeconfig with its tokens, passwords and keys masked
+	kubectl cfg merge config -f import-kubeconfig01.yaml -f import-kubeconfig02.yaml
+
+	# Merge a kubeconfig read from the standard input and a base64 encoded one, without writing them to disk
+	kubectl get secret prod-kubeconfig -o jsonpath='{.data.value}' | kubectl cfg merge config -f - --from-base64="$CI_KUBECONFIG"`
 	addConfigExample = templates.Examples(exampleString)
 
 	errorString = `
     Kubeconfig file path is need.
     # Merge the kubeconfig into the output kubeconfig file
-	kubectl cfg merge config -f import-kubeconfig01.yaml -f import-kubeconfig02.yaml > export-kubeconfig.yaml`
+	kubectl cfg merge config -f import-kubeconfig01.yaml -f import-kubeconfig02.yaml --show-secrets > export-kubeconfig.yaml`
 	errorExample = templates.Examples(errorString)
-
-	kubeconfigFiles []string
-	kubeconfig      string
 )
 
 // NewCmdConfigView returns a Command instance for 'config view' sub command
@@ -44,16 +56,17 @@ func NewCmdCfgMergeConfig(streams genericclioptions.IOStreams, ConfigAccess clie
 		Flatten:      true,
 		IOStreams:    streams,
 	}
+	showSecrets := false
+	inputs := &mergeInputs{}
 
 	cmd := &cobra.Command{
-		Use:     fmt.Sprintf("config [--%v=path/kubeconfg] ", kubeconfigFlag),
+		Use:     fmt.Sprintf("config [--%v=path/kubeconfg|-] [--%v=base64]", kubeconfigFlag, kubeconfig.FlagFromBase64),
 		Short:   i18n.T("Merge multi the kubeconfig files"),
 		Long:    addConfigLong,
 		Example: addConfigExample,
 		Run: func(cmd *cobra.Command, args []string) {
-			cmdutil.CheckErr(SetFilePaths(cmd))
 			cmdutil.CheckErr(o.Complete(cmd, args))
-			cmdutil.CheckErr(o.Run())
+			cmdutil.CheckErr(RunMergeConfig(o, inputs, showSecrets))
 		},
 	}
 
@@ -66,29 +79,105 @@ func NewCmdCfgMergeConfig(streams genericclioptions.IOStreams, ConfigAccess clie
 	//cmd.Flags().BoolVar(&o.RawByteData, "raw", o.RawByteData, "Display raw byte data")
 	//cmd.Flags().BoolVar(&o.Flatten, "flatten", o.Flatten, "Flatten the resulting kubeconfig file into self-contained output (useful for creating portable kubeconfig files)")
 	//cmd.Flags().BoolVar(&o.Minify, "minify", o.Minify, "Remove all information not used by current-context from the output")
-	cmd.Flags().StringSliceP(kubeconfigFlag, "f", kubeconfigFiles, "Merged the kubeconfig")
+	cmd.Flags().StringSliceVarP(&inputs.files, kubeconfigFlag, "f", inputs.files, "Merged the kubeconfig, - to read it from the standard input")
+	cmd.Flags().StringArrayVar(&inputs.base64s, kubeconfig.FlagFromBase64, inputs.base64s, "Merged the base64 encoded kubeconfig")
 	cmd.Flags().String("context", "", "The name of the kubeconfig context to use")
+	cmd.Flags().BoolVar(&showSecrets, "show-secrets", showSecrets, "Print the tokens, passwords, client keys, auth provider secrets and exec env values instead of masking them")
 	return cmd
 }
 
-func SetFilePaths(cmd *cobra.Command) error {
+// mergeInputs are the kubeconfigs to merge, in precedence order.
+type mergeInputs struct {
+	files   []string
+	base64s []string
+}
 
-	filePaths, err := cmd.Flags().GetStringSlice("file")
+// load loads and merges the kubeconfigs, each entry and the current context being taken from the first one defining it.
+func (m *mergeInputs) load(in io.Reader) (*clientcmdapi.Config, error) {
+	if len(m.files) == 0 && len(m.base64s) == 0 {
+		return nil, errors.New(errorExample)
+	}
 
-	if err != nil {
-		return err
+	configs := []*clientcmdapi.Config{}
+	stdinRead := false
+	for _, file := range m.files {
+		if file == kubeconfig.StdinFile {
+			if stdinRead {
+				return nil, errors.New("the standard input can only be merged once")
+			}
+			stdinRead = true
+		}
+		config, err := kubeconfig.LoadInput(file, in)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+	}
+	for _, value := range m.base64s {
+		config, err := kubeconfig.LoadBase64(value)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, config)
+	}
+
+	merged := clientcmdapi.NewConfig()
+	for _, config := range configs {
+		if len(merged.CurrentContext) == 0 {
+			merged.CurrentContext = config.CurrentContext
+		}
+		for name, cluster := range config.Clusters {
+			if _, ok := merged.Clusters[name]; !ok {
+				merged.Clusters[name] = cluster
+			}
+		}
+		for name, authInfo := range config.AuthInfos {
+			if _, ok := merged.AuthInfos[name]; !ok {
+				merged.AuthInfos[name] = authInfo
+			}
+		}
+		for name, context := range config.Contexts {
+			if _, ok := merged.Contexts[name]; !ok {
+				merged.Contexts[name] = context
+			}
+		}
+		for name, extension := range config.Extensions {
+			if _, ok := merged.Extensions[name]; !ok {
+				merged.Extensions[name] = extension
+			}
+		}
 	}
+	return merged, nil
+}
 
-	if len(filePaths) == 0 {
-		return errors.New(errorExample)
+// RunMergeConfig prints the flattened merge of the kubeconfigs like 'kubectl config view --flatten',
+// with the secrets masked unless showSecrets is set.
+func RunMergeConfig(o *kconf.ViewOptions, inputs *mergeInputs, showSecrets bool) error {
+	if err := o.Validate(); err != nil {
+		return err
 	}
 
-	importKubeconfig := strings.Replace(strings.Trim(fmt.Sprint(filePaths), "[]"), " ", ":", -1)
+	config, err := inputs.load(o.In)
+	if err != nil {
+		return err
+	}
 
-	err = os.Setenv("KUBECONFIG", importKubeconfig)
+	if err := clientcmdapi.FlattenConfig(config); err != nil {
+		return err
+	}
+	if !showSecrets && redact.Config(config) {
+		// a masked kubeconfig redirected to a file can't be used, which the info line is easily missed for
+		if term.IsTerminal(o.Out) {
+			fmt.Fprintln(o.ErrOut, "info: secrets are masked, use --show-secrets to print a usable kubeconfig")
+		} else {
+			fmt.Fprintln(o.ErrOut, "warning: the tokens, passwords and keys of the merged kubeconfig are written as REDACTED and it cannot be used to connect, use --show-secrets to write a usable kubeconfig")
+		}
+	}
 
+	convertedObj, err := latest.Scheme.ConvertToVersion(config, latest.ExternalVersion)
 	if err != nil {
 		return err
 	}
-	return nil
+
+	return o.PrintObject(convertedObj, o.Out)
 }
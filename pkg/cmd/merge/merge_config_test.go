@@ -0,0 +1,101 @@
+package merge
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// secretKubeconfig has a user with every kind of secret, and the secrets which must be masked.
+var secretKubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: c
+  cluster:
+    server: https://127.0.0.1:6443
+users:
+- name: u
+  user:
+    token: token-secret-1
+    username: admin
+    password: password-secret-2
+    client-key-data: ` + base64.StdEncoding.EncodeToString([]byte("key-secret-3")) + `
+    auth-provider:
+      name: oidc
+      config:
+        client-id: visible-client-id
+        client-secret: client-secret-4
+        id-token: id-token-secret-5
+    exec:
+      apiVersion: client.authentication.k8s.io/v1beta1
+      command: kubelogin
+      env:
+      - name: LOGIN_PASSWORD
+        value: env-secret-6
+contexts:
+- name: ctx
+  context:
+    cluster: c
+    user: u
+current-context: ctx
+`
+
+var mergeSecrets = []string{
+	"token-secret-1",
+	"password-secret-2",
+	base64.StdEncoding.EncodeToString([]byte("key-secret-3")),
+	"client-secret-4",
+	"id-token-secret-5",
+	"env-secret-6",
+}
+
+func TestMergeConfigSecrets(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "config")
+	if err := ioutil.WriteFile(file, []byte(secretKubeconfig), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, showSecrets := range []bool{false, true} {
+		streams, _, out, errOut := genericclioptions.NewTestIOStreams()
+		cmd := NewCmdCfgMergeConfig(streams, clientcmd.NewDefaultPathOptions())
+		args := []string{"-f", file}
+		if showSecrets {
+			args = append(args, "--show-secrets")
+		}
+		cmd.SetArgs(args)
+		if err := cmd.Execute(); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, secret := range mergeSecrets {
+			if shown := strings.Contains(out.String(), secret); shown != showSecrets {
+				t.Errorf("show-secrets=%v: expected %s shown to be %v in:\n%s", showSecrets, secret, showSecrets, out)
+			}
+		}
+		if !strings.Contains(out.String(), "visible-client-id") {
+			t.Errorf("show-secrets=%v: expected the client-id not to be masked in:\n%s", showSecrets, out)
+		}
+		if warned := strings.Contains(errOut.String(), "--show-secrets"); warned == showSecrets {
+			t.Errorf("show-secrets=%v: unexpected warning %q", showSecrets, errOut)
+		}
+	}
+}
+
+func TestMergeConfigSecretsOfStdin(t *testing.T) {
+	streams, in, out, _ := genericclioptions.NewTestIOStreams()
+	in.WriteString(secretKubeconfig)
+	cmd := NewCmdCfgMergeConfig(streams, clientcmd.NewDefaultPathOptions())
+	cmd.SetArgs([]string{"-f", "-"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "token-secret-1") || !bytes.Contains(out.Bytes(), []byte("REDACTED")) {
+		t.Errorf("expected the token of the standard input to be masked in:\n%s", out)
+	}
+}
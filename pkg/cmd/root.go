@@ -6,11 +6,15 @@ import (
 
 	"github.com/it2911/kubectl-cfg/pkg/cmd/add"
 	"github.com/it2911/kubectl-cfg/pkg/cmd/delete"
+	"github.com/it2911/kubectl-cfg/pkg/cmd/doctor"
+	"github.com/it2911/kubectl-cfg/pkg/cmd/get"
 	"github.com/it2911/kubectl-cfg/pkg/cmd/list"
 	"github.com/it2911/kubectl-cfg/pkg/cmd/rename"
 	"github.com/it2911/kubectl-cfg/pkg/cmd/merge"
+	"github.com/it2911/kubectl-cfg/pkg/cmd/trash"
 	"github.com/it2911/kubectl-cfg/pkg/cmd/use"
 	"github.com/it2911/kubectl-cfg/pkg/cmd/version"
+	"github.com/it2911/kubectl-cfg/pkg/util/kubeconfig"
 	"k8s.io/kubectl/pkg/util/templates"
 
 	"github.com/spf13/cobra"
@@ -40,17 +44,26 @@ func NewCmdCfg(f cmdutil.Factory, pathOptions *clientcmd.PathOptions, streams ge
 	}
 
 	// file paths are common to all sub commands
-	cmd.PersistentFlags().StringVar(&pathOptions.LoadingRules.ExplicitPath, pathOptions.ExplicitFileFlag, pathOptions.LoadingRules.ExplicitPath, "use a particular kubeconfig file")
+	cmd.PersistentFlags().StringVar(&pathOptions.LoadingRules.ExplicitPath, pathOptions.ExplicitFileFlag, pathOptions.LoadingRules.ExplicitPath, "use a particular kubeconfig file, - to read it from the standard input")
+
+	// the read only commands also read the kubeconfig from the standard input or from base64
+	configAccess := kubeconfig.NewInputConfigAccess(pathOptions)
+	cmd.PersistentFlags().StringVar(&configAccess.Base64, kubeconfig.FlagFromBase64, configAccess.Base64, "use a base64 encoded kubeconfig, for the commands which do not write it")
+	cmd.PersistentPreRun = func(cmd *cobra.Command, args []string) {
+		cmdutil.CheckErr(configAccess.Load(cmd, streams.In))
+	}
 
 	// TODO(juanvallejo): update all subcommands to work with genericclioptions.IOStreams
-	cmd.AddCommand(add.NewCmdCfgAdd(streams, pathOptions))
-	cmd.AddCommand(delete.NewCmdCfgDelete(streams, pathOptions))
-	//cmd.AddCommand(get.NewCmdCfgGet(streams, pathOptions))
-	cmd.AddCommand(rename.NewCmdCfgRenameContext(streams, pathOptions))
-	cmd.AddCommand(list.NewCmdCfgList(streams, pathOptions))
-	cmd.AddCommand(use.NewCmdCfgUseContext(streams.Out, pathOptions))
-	cmd.AddCommand(merge.NewCmdCfgMerge(streams, pathOptions))
-	cmd.AddCommand(version.NewCmdCfgVersion(streams.Out, pathOptions))
+	cmd.AddCommand(add.NewCmdCfgAdd(streams, configAccess))
+	cmd.AddCommand(delete.NewCmdCfgDelete(streams, configAccess))
+	cmd.AddCommand(get.NewCmdCfgGet(streams, configAccess))
+	cmd.AddCommand(rename.NewCmdCfgRenameContext(streams, configAccess))
+	cmd.AddCommand(list.NewCmdCfgList(streams, configAccess))
+	cmd.AddCommand(use.NewCmdCfgUseContext(streams.Out, configAccess))
+	cmd.AddCommand(merge.NewCmdCfgMerge(streams, configAccess))
+	cmd.AddCommand(version.NewCmdCfgVersion(streams.Out, configAccess))
+	cmd.AddCommand(doctor.NewCmdCfgDoctor(streams, configAccess))
+	cmd.AddCommand(trash.NewCmdCfgTrash(streams, configAccess))
 
 	return cmd
 }
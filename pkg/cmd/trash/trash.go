@@ -0,0 +1,95 @@
+package trash
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/it2911/kubectl-cfg/pkg/util/trash"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/clientcmd"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	trashLong = templates.LongDesc(`
+		List, restore and purge the entries deleted by 'kubectl cfg delete' and 'kubectl cfg doctor --fix'.
+
+		The deleted contexts, clusters and users are kept with the kubeconfig file they were deleted
+		from in kubectl-cfg-trash.yaml, next to the kubeconfig file, or in the backup directory set by
+		$KUBECTL_CFG_BACKUP_DIR or the backup.dir of the settings. The oldest entries are dropped beyond
+		the backup.maxCount, backup.maxSize and backup.maxAge of the settings, 100 entries, 10Mi and 90d
+		by default.
+
+		The entries which the previous versions appended to ~/.kube/kubectl-cfg-delete-bak.yaml are moved
+		into the trash the first time it is opened, and the file is renamed to
+		kubectl-cfg-delete-bak.yaml.migrated.`)
+
+	trashExample = templates.Examples(`
+		# List the deleted entries
+		kubectl cfg trash list
+
+		# Restore the minikube context, cluster and user deleted last
+		kubectl cfg trash restore minikube
+
+		# Purge the entries deleted more than 30 days ago
+		kubectl cfg trash purge --older-than=30d`)
+)
+
+// NewCmdCfgTrash returns a Command instance for 'cfg trash' sub command
+func NewCmdCfgTrash(streams genericclioptions.IOStreams, configAccess clientcmd.ConfigAccess) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:                   "trash",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("List, restore and purge the deleted entries"),
+		Long:                  trashLong,
+		Example:               trashExample,
+		Run:                   cmdutil.DefaultSubCommandRun(streams.ErrOut),
+	}
+
+	cmd.AddCommand(NewCmdCfgTrashList(streams, configAccess))
+	cmd.AddCommand(NewCmdCfgTrashRestore(streams, configAccess))
+	cmd.AddCommand(NewCmdCfgTrashPurge(streams, configAccess))
+	return cmd
+}
+
+// kinds are the values of --kind.
+var kinds = []string{trash.KindContext, trash.KindCluster, trash.KindUser}
+
+// validateKind checks the value of --kind, which is empty for every kind.
+func validateKind(kind string) error {
+	if len(kind) == 0 {
+		return nil
+	}
+	for _, k := range kinds {
+		if kind == k {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid --kind %q, must be one of: %s", kind, strings.Join(kinds, ", "))
+}
+
+// findRecords returns the records of the ID or NAME args, and an error naming the args which match nothing.
+func findRecords(store *trash.Store, args []string, kind string) ([]trash.Record, error) {
+	records := []trash.Record{}
+	seen := map[int]bool{}
+	notFound := []string{}
+	for _, arg := range args {
+		found := store.Find(arg, kind)
+		if len(found) == 0 {
+			notFound = append(notFound, arg)
+		}
+		for _, r := range found {
+			if !seen[r.ID] {
+				seen[r.ID] = true
+				records = append(records, r)
+			}
+		}
+	}
+	if len(notFound) != 0 {
+		return nil, fmt.Errorf("%s not found in the trash %s", strings.Join(notFound, ", "), store.File())
+	}
+	return records, nil
+}
@@ -0,0 +1,126 @@
+package trash
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/it2911/kubectl-cfg/pkg/util/kubeconfig"
+	"github.com/it2911/kubectl-cfg/pkg/util/printers"
+	"github.com/it2911/kubectl-cfg/pkg/util/selector"
+	"github.com/it2911/kubectl-cfg/pkg/util/trash"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/duration"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/clientcmd"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	trashListLong = templates.LongDesc(`
+		List the entries of the trash, oldest first, with the kubeconfig file they were deleted from and
+		the command which deleted them.`)
+
+	trashListExample = templates.Examples(`
+		# List the deleted entries
+		kubectl cfg trash list
+
+		# List the deleted clusters whose name starts with eks-
+		kubectl cfg trash list 'eks-*' --kind=cluster`)
+)
+
+// TrashListOptions contains the assignable options from the args.
+type TrashListOptions struct {
+	configAccess clientcmd.ConfigAccess
+	kind         string
+	patterns     []selector.Pattern
+
+	genericclioptions.IOStreams
+}
+
+// NewCmdCfgTrashList returns a Command instance for 'cfg trash list' sub command
+func NewCmdCfgTrashList(streams genericclioptions.IOStreams, configAccess clientcmd.ConfigAccess) *cobra.Command {
+	options := &TrashListOptions{configAccess: configAccess, IOStreams: streams}
+
+	cmd := &cobra.Command{
+		Use:                   "list [NAME_PATTERN...] [--kind=context|cluster|user]",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("List the deleted entries"),
+		Long:                  trashListLong,
+		Example:               trashListExample,
+		Annotations:           map[string]string{kubeconfig.ReadOnlyAnnotation: "true"},
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(options.Complete(args))
+			cmdutil.CheckErr(options.RunTrashList())
+		},
+	}
+
+	cmd.Flags().StringVar(&options.kind, "kind", options.kind, "Only list the entries of this kind, one of: context, cluster, user")
+	return cmd
+}
+
+// Complete parses the name patterns and checks --kind.
+func (o *TrashListOptions) Complete(args []string) error {
+	for _, arg := range args {
+		pattern, err := selector.ParsePattern(arg)
+		if err != nil {
+			return err
+		}
+		o.patterns = append(o.patterns, pattern)
+	}
+	return validateKind(o.kind)
+}
+
+// RunTrashList prints the records which match the patterns and --kind.
+func (o *TrashListOptions) RunTrashList() error {
+	store, err := trash.Open(o.configAccess)
+	if err != nil {
+		return err
+	}
+
+	records := []trash.Record{}
+	for _, r := range store.Records {
+		if (len(o.kind) == 0 || r.Kind == o.kind) && o.matches(r.Name) {
+			records = append(records, r)
+		}
+	}
+	if len(records) == 0 {
+		fmt.Fprintln(o.Out, "The trash is empty.")
+		return nil
+	}
+
+	w := printers.GetNewTabWriter(o.Out)
+	defer w.Flush()
+
+	if _, err := fmt.Fprintln(w, "ID\tKIND\tNAME\tSOURCE\tDELETED\tCOMMAND"); err != nil {
+		return err
+	}
+	for _, r := range records {
+		source := r.Source
+		if len(source) == 0 {
+			source = "-"
+		}
+		command := r.Command
+		if len(command) == 0 {
+			command = "-"
+		}
+		age := duration.HumanDuration(time.Since(r.Deleted)) + " ago"
+		if _, err := fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\n", r.ID, r.Kind, r.Name, source, age, command); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (o *TrashListOptions) matches(name string) bool {
+	if len(o.patterns) == 0 {
+		return true
+	}
+	for _, pattern := range o.patterns {
+		if pattern.Match(name) {
+			return true
+		}
+	}
+	return false
+}
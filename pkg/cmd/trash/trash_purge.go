@@ -0,0 +1,130 @@
+package trash
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/it2911/kubectl-cfg/pkg/util/settings"
+	"github.com/it2911/kubectl-cfg/pkg/util/trash"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/clientcmd"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	trashPurgeLong = templates.LongDesc(`
+		Permanently remove entries from the trash.
+
+		The entries are given by their ID or their name, or selected by the time they were deleted
+		with --older-than, which accepts durations like 12h or 30d. --all empties the trash.`)
+
+	trashPurgeExample = templates.Examples(`
+		# Purge the entries deleted more than 30 days ago
+		kubectl cfg trash purge --older-than=30d
+
+		# Purge the entry of ID 12
+		kubectl cfg trash purge 12
+
+		# Empty the trash
+		kubectl cfg trash purge --all`)
+)
+
+// TrashPurgeOptions contains the assignable options from the args.
+type TrashPurgeOptions struct {
+	configAccess clientcmd.ConfigAccess
+	olderThan    string
+	all          bool
+	kind         string
+	args         []string
+
+	age time.Duration
+
+	genericclioptions.IOStreams
+}
+
+// NewCmdCfgTrashPurge returns a Command instance for 'cfg trash purge' sub command
+func NewCmdCfgTrashPurge(streams genericclioptions.IOStreams, configAccess clientcmd.ConfigAccess) *cobra.Command {
+	options := &TrashPurgeOptions{configAccess: configAccess, IOStreams: streams}
+
+	cmd := &cobra.Command{
+		Use:                   "purge [ID|NAME...] [--older-than=DURATION] [--all] [--kind=context|cluster|user]",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Permanently remove deleted entries"),
+		Long:                  trashPurgeLong,
+		Example:               trashPurgeExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(options.Complete(cmd, args))
+			cmdutil.CheckErr(options.RunTrashPurge())
+		},
+	}
+
+	cmd.Flags().StringVar(&options.olderThan, "older-than", options.olderThan, "Only purge the entries deleted longer ago than this duration, like 12h or 30d")
+	cmd.Flags().BoolVar(&options.all, "all", options.all, "Purge every entry of the trash")
+	cmd.Flags().StringVar(&options.kind, "kind", options.kind, "Only purge the entries of this kind, one of: context, cluster, user")
+	return cmd
+}
+
+// Complete assigns TrashPurgeOptions from the args and parses --older-than.
+func (o *TrashPurgeOptions) Complete(cmd *cobra.Command, args []string) error {
+	o.args = args
+	if len(args) == 0 && len(o.olderThan) == 0 && !o.all {
+		return cmdutil.UsageErrorf(cmd, "you must specify entries of the trash, --older-than or --all")
+	}
+	if o.all && len(args) != 0 {
+		return errors.New("you cannot specify entries and --all at the same time")
+	}
+	if len(o.olderThan) != 0 {
+		age, err := settings.ParseAge(o.olderThan)
+		if err != nil {
+			return err
+		}
+		o.age = age
+	}
+	return validateKind(o.kind)
+}
+
+// RunTrashPurge removes the selected records from the trash.
+func (o *TrashPurgeOptions) RunTrashPurge() error {
+	store, err := trash.Open(o.configAccess)
+	if err != nil {
+		return err
+	}
+
+	records := store.Records
+	if len(o.args) != 0 {
+		if records, err = findRecords(store, o.args, o.kind); err != nil {
+			return err
+		}
+	}
+
+	deadline := time.Now().Add(-o.age)
+	purged := []trash.Record{}
+	ids := []int{}
+	for _, r := range records {
+		if len(o.kind) != 0 && r.Kind != o.kind {
+			continue
+		}
+		if len(o.olderThan) != 0 && !r.Deleted.Before(deadline) {
+			continue
+		}
+		purged = append(purged, r)
+		ids = append(ids, r.ID)
+	}
+	if len(ids) == 0 {
+		fmt.Fprintln(o.Out, "Nothing to purge.")
+		return nil
+	}
+
+	store.Remove(ids...)
+	if err := store.Save(); err != nil {
+		return err
+	}
+	for _, r := range purged {
+		fmt.Fprintf(o.Out, "purged %s %s (%d)\n", r.Kind, r.Name, r.ID)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package trash
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/it2911/kubectl-cfg/pkg/util/kubeconfig"
+	"github.com/it2911/kubectl-cfg/pkg/util/trash"
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	cmdutil "k8s.io/kubectl/pkg/cmd/util"
+	"k8s.io/kubectl/pkg/util/i18n"
+	"k8s.io/kubectl/pkg/util/templates"
+)
+
+var (
+	trashRestoreLong = templates.LongDesc(`
+		Restore entries of the trash into the kubeconfig.
+
+		An entry is given by its ID, or by its name to restore the entry of each kind deleted last
+		with that name, --kind restricting it to one kind. An entry is restored into the kubeconfig
+		file it was deleted from when that file is still loaded, or into the file of --kubeconfig.
+		An entry whose name is taken in the meantime is restored as NAME-N, and the restored
+		contexts follow the renamed clusters and users restored with them.`)
+
+	trashRestoreExample = templates.Examples(`
+		# Restore the entry of ID 12
+		kubectl cfg trash restore 12
+
+		# Restore the minikube context, cluster and user deleted last
+		kubectl cfg trash restore minikube
+
+		# Only restore the minikube cluster
+		kubectl cfg trash restore minikube --kind=cluster`)
+)
+
+// TrashRestoreOptions contains the assignable options from the args.
+type TrashRestoreOptions struct {
+	configAccess clientcmd.ConfigAccess
+	kind         string
+	args         []string
+
+	genericclioptions.IOStreams
+}
+
+// NewCmdCfgTrashRestore returns a Command instance for 'cfg trash restore' sub command
+func NewCmdCfgTrashRestore(streams genericclioptions.IOStreams, configAccess clientcmd.ConfigAccess) *cobra.Command {
+	options := &TrashRestoreOptions{configAccess: configAccess, IOStreams: streams}
+
+	cmd := &cobra.Command{
+		Use:                   "restore ID|NAME... [--kind=context|cluster|user]",
+		DisableFlagsInUseLine: true,
+		Short:                 i18n.T("Restore deleted entries into the kubeconfig"),
+		Long:                  trashRestoreLong,
+		Example:               trashRestoreExample,
+		Run: func(cmd *cobra.Command, args []string) {
+			cmdutil.CheckErr(options.Complete(cmd, args))
+			cmdutil.CheckErr(options.RunTrashRestore())
+		},
+	}
+
+	cmd.Flags().StringVar(&options.kind, "kind", options.kind, "Only restore the entries of this kind, one of: context, cluster, user")
+	return cmd
+}
+
+// Complete assigns TrashRestoreOptions from the args.
+func (o *TrashRestoreOptions) Complete(cmd *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return cmdutil.UsageErrorf(cmd, "you must specify the ID or the NAME of an entry of the trash")
+	}
+	o.args = args
+	return validateKind(o.kind)
+}
+
+// RunTrashRestore inserts the records into the kubeconfig, and removes them from the trash once written.
+func (o *TrashRestoreOptions) RunTrashRestore() error {
+	store, err := trash.Open(o.configAccess)
+	if err != nil {
+		return err
+	}
+	records, err := findRecords(store, o.args, o.kind)
+	if err != nil {
+		return err
+	}
+	config, err := o.configAccess.GetStartingConfig()
+	if err != nil {
+		return err
+	}
+
+	restoredEntries, err := restore(config, records, o.destination)
+	if err != nil {
+		return err
+	}
+	messages := []string{}
+	ids := []int{}
+	for _, restored := range restoredEntries {
+		r, destination := restored.record, restored.destination
+		if len(destination) == 0 {
+			destination = o.configAccess.GetDefaultFilename()
+		}
+		if restored.name == r.Name {
+			messages = append(messages, fmt.Sprintf("restored %s %s into %s", r.Kind, r.Name, destination))
+		} else {
+			messages = append(messages, fmt.Sprintf("restored %s %s as %s into %s, as %s is taken", r.Kind, r.Name, restored.name, destination, r.Name))
+		}
+		ids = append(ids, r.ID)
+	}
+
+	if err := clientcmd.ModifyConfig(o.configAccess, *config, true); err != nil {
+		return err
+	}
+	for _, message := range messages {
+		fmt.Fprintln(o.Out, message)
+	}
+
+	store.Remove(ids...)
+	if err := store.Save(); err != nil {
+		fmt.Fprintf(o.ErrOut, "warning: the restored entries could not be removed from the trash: %v\n", err)
+	}
+	return nil
+}
+
+// restoredEntry is a record restored under name into destination, the empty one standing for the default file.
+type restoredEntry struct {
+	record      trash.Record
+	name        string
+	destination string
+}
+
+// restore inserts the entries of the records into config, the ones deleted from source going into
+// destination(source). The clusters and the users are restored first, for the contexts to follow their renames.
+func restore(config *clientcmdapi.Config, records []trash.Record, destination func(source string) string) ([]restoredEntry, error) {
+	records = append([]trash.Record{}, records...)
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].Kind != trash.KindContext && records[j].Kind == trash.KindContext
+	})
+
+	renamed := map[string]map[string]string{trash.KindCluster: {}, trash.KindUser: {}}
+	restoredEntries := []restoredEntry{}
+	for _, r := range records {
+		entry, err := r.Entry()
+		if err != nil {
+			return nil, err
+		}
+		restored := restoredEntry{record: r, name: r.Name, destination: destination(r.Source)}
+
+		switch e := entry.(type) {
+		case *clientcmdapi.Cluster:
+			restored.name = restoredName(r.Name, func(n string) bool { _, ok := config.Clusters[n]; return ok })
+			e.LocationOfOrigin = restored.destination
+			config.Clusters[restored.name] = e
+			renamed[trash.KindCluster][r.Name] = restored.name
+		case *clientcmdapi.AuthInfo:
+			restored.name = restoredName(r.Name, func(n string) bool { _, ok := config.AuthInfos[n]; return ok })
+			e.LocationOfOrigin = restored.destination
+			config.AuthInfos[restored.name] = e
+			renamed[trash.KindUser][r.Name] = restored.name
+		case *clientcmdapi.Context:
+			restored.name = restoredName(r.Name, func(n string) bool { _, ok := config.Contexts[n]; return ok })
+			if newName, ok := renamed[trash.KindCluster][e.Cluster]; ok {
+				e.Cluster = newName
+			}
+			if newName, ok := renamed[trash.KindUser][e.AuthInfo]; ok {
+				e.AuthInfo = newName
+			}
+			e.LocationOfOrigin = restored.destination
+			config.Contexts[restored.name] = e
+		}
+		restoredEntries = append(restoredEntries, restored)
+	}
+	return restoredEntries, nil
+}
+
+// destination returns the file to restore an entry deleted from source into: the --kubeconfig file, or
+// source when it is still loaded, or the default file which is named by the empty string.
+func (o *TrashRestoreOptions) destination(source string) string {
+	if o.configAccess.IsExplicitFile() {
+		return o.configAccess.GetExplicitFile()
+	}
+	for _, file := range o.configAccess.GetLoadingPrecedence() {
+		if file == source {
+			return source
+		}
+	}
+	return ""
+}
+
+// restoredName returns name, or the first name-N which is not taken when name is.
+func restoredName(name string, taken func(string) bool) string {
+	if !taken(name) {
+		return name
+	}
+	return kubeconfig.FreeName(name, taken)
+}
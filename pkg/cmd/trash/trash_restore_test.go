@@ -0,0 +1,96 @@
+package trash
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/it2911/kubectl-cfg/pkg/util/trash"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestRestore(t *testing.T) {
+	store, err := trash.Load("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	adds := []struct {
+		kind, name string
+		entry      interface{}
+	}{
+		// the context is restored after the cluster and the user it follows, whatever the order
+		{trash.KindContext, "prod", &clientcmdapi.Context{LocationOfOrigin: "/home/config", Cluster: "prod-c", AuthInfo: "admin"}},
+		{trash.KindCluster, "prod-c", &clientcmdapi.Cluster{LocationOfOrigin: "/home/config", Server: "https://10.0.0.1"}},
+		{trash.KindUser, "admin", &clientcmdapi.AuthInfo{LocationOfOrigin: "/gone/config", Token: "deleted"}},
+		{trash.KindContext, "dev", &clientcmdapi.Context{LocationOfOrigin: "/home/config", Cluster: "dev-c", AuthInfo: "admin"}},
+	}
+	for _, add := range adds {
+		if _, err := store.Add(add.kind, add.name, add.entry, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// prod-c and prod are taken in the meantime, and prod-c-1 too
+	config := clientcmdapi.NewConfig()
+	config.Clusters["prod-c"] = &clientcmdapi.Cluster{Server: "https://127.0.0.1:1"}
+	config.Clusters["prod-c-1"] = &clientcmdapi.Cluster{Server: "https://127.0.0.1:2"}
+	config.Clusters["dev-c"] = &clientcmdapi.Cluster{Server: "https://127.0.0.1:3"}
+	config.Contexts["prod"] = &clientcmdapi.Context{Cluster: "prod-c"}
+
+	destination := func(source string) string {
+		if source == "/home/config" {
+			return source
+		}
+		return ""
+	}
+	restored, err := restore(config, store.Records, destination)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	names := map[string]string{}
+	destinations := map[string]string{}
+	for _, r := range restored {
+		names[r.record.Kind+"/"+r.record.Name] = r.name
+		destinations[r.record.Kind+"/"+r.record.Name] = r.destination
+	}
+	expectedNames := map[string]string{
+		"cluster/prod-c": "prod-c-2",
+		"user/admin":     "admin",
+		"context/prod":   "prod-1",
+		"context/dev":    "dev",
+	}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Errorf("expected the names %v, got %v", expectedNames, names)
+	}
+	expectedDestinations := map[string]string{
+		"cluster/prod-c": "/home/config",
+		"user/admin":     "",
+		"context/prod":   "/home/config",
+		"context/dev":    "/home/config",
+	}
+	if !reflect.DeepEqual(destinations, expectedDestinations) {
+		t.Errorf("expected the destinations %v, got %v", expectedDestinations, destinations)
+	}
+
+	contexts := map[string][2]string{}
+	for name, context := range config.Contexts {
+		contexts[name] = [2]string{context.Cluster, context.AuthInfo}
+	}
+	expectedContexts := map[string][2]string{
+		"prod":   {"prod-c", ""},
+		"prod-1": {"prod-c-2", "admin"},
+		"dev":    {"dev-c", "admin"},
+	}
+	if !reflect.DeepEqual(contexts, expectedContexts) {
+		t.Errorf("expected the contexts %v, got %v", expectedContexts, contexts)
+	}
+	if cluster := config.Clusters["prod-c-2"]; cluster == nil || cluster.Server != "https://10.0.0.1" || cluster.LocationOfOrigin != "/home/config" {
+		t.Errorf("expected the restored cluster as prod-c-2, got %v", cluster)
+	}
+	if config.Clusters["prod-c"].Server != "https://127.0.0.1:1" {
+		t.Errorf("expected the existing prod-c to be kept, got %v", config.Clusters["prod-c"])
+	}
+	if authInfo := config.AuthInfos["admin"]; authInfo == nil || authInfo.Token != "deleted" || authInfo.LocationOfOrigin != "" {
+		t.Errorf("expected the restored user into the default file, got %v", authInfo)
+	}
+}
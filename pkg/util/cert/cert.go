@@ -0,0 +1,89 @@
+package cert
+
+import (
+	"crypto/x509"
+	"errors"
+	"io/ioutil"
+	"time"
+
+	certutil "k8s.io/client-go/util/cert"
+)
+
+const (
+	// DefaultExpiryWarning is how long before its expiry a certificate starts to be reported as expiring.
+	DefaultExpiryWarning = 30 * 24 * time.Hour
+
+	dateFormat = "2006-01-02"
+)
+
+// ExpiryState tells whether a certificate is valid, about to expire or already expired.
+type ExpiryState int
+
+const (
+	Valid ExpiryState = iota
+	Expiring
+	Expired
+)
+
+// LoadCertificates parses the PEM encoded certificates from data, or from file when data is empty.
+// It returns nil without error when neither is set.
+func LoadCertificates(data []byte, file string) ([]*x509.Certificate, error) {
+	if len(data) == 0 {
+		if len(file) == 0 {
+			return nil, nil
+		}
+		var err error
+		if data, err = ioutil.ReadFile(file); err != nil {
+			return nil, err
+		}
+	}
+
+	return certutil.ParseCertsPEM(data)
+}
+
+// Shortest returns the certificate of the bundle which expires first.
+func Shortest(certs []*x509.Certificate) (*x509.Certificate, error) {
+	if len(certs) == 0 {
+		return nil, errors.New("no certificate found")
+	}
+
+	shortest := certs[0]
+	for _, c := range certs[1:] {
+		if c.NotAfter.Before(shortest.NotAfter) {
+			shortest = c
+		}
+	}
+	return shortest, nil
+}
+
+// State returns the ExpiryState of notAfter, considering it expiring within the warning window.
+func State(notAfter time.Time, warning time.Duration) ExpiryState {
+	now := time.Now()
+	if now.After(notAfter) {
+		return Expired
+	}
+	if now.Add(warning).After(notAfter) {
+		return Expiring
+	}
+	return Valid
+}
+
+// FormatDate formats the expiry date the way it is shown in tables.
+func FormatDate(t time.Time) string {
+	return t.Format(dateFormat)
+}
+
+// FormatValidity formats the NotAfter date of the first certificate of a bundle, and the NotAfter
+// date of the shortest-lived certificate when it expires earlier.
+func FormatValidity(certs []*x509.Certificate) string {
+	shortest, err := Shortest(certs)
+	if err != nil {
+		return ""
+	}
+
+	validity := FormatDate(certs[0].NotAfter)
+	if shortest != certs[0] {
+		validity += " (shortest " + FormatDate(shortest.NotAfter) + ")"
+	}
+	return validity
+}
@@ -0,0 +1,230 @@
+package health
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	StatusUnknown     = "UNKNOWN"
+	StatusNoServer    = "NO_SERVER"
+	StatusInvalid     = "INVALID_CONFIG"
+	StatusTimeout     = "TIMEOUT"
+	StatusTLSError    = "TLS_ERROR"
+	StatusUnreachable = "UNREACHABLE"
+	StatusNotReady    = "NOT_READY"
+
+	DefaultWorkers = 10
+	DefaultTimeout = 5 * time.Second
+
+	probeContextName = "kubectl-cfg-probe"
+)
+
+// Options controls how many clusters are probed at once and how long each one may take.
+// The clusters of users with an exec credential plugin are probed anonymously unless Exec is set,
+// as running the plugins may be slow, prompt for a login or have side effects.
+type Options struct {
+	Workers int
+	Timeout time.Duration
+	Exec    bool
+}
+
+// Result is the outcome of probing the /healthz, /readyz and /version endpoints of one API server.
+type Result struct {
+	Healthz string
+	Readyz  string
+	Version string
+}
+
+// Status returns the value shown in the STATUS_CODE column.
+// A healthy server which reports itself as not ready is shown as NOT_READY.
+func (r Result) Status() string {
+	if r.Healthz == "200" && len(r.Readyz) != 0 && r.Readyz != "200" && r.Readyz != "404" {
+		return StatusNotReady
+	}
+	return r.Healthz
+}
+
+// ProbeClusters probes the API server of every named cluster using a bounded pool of workers.
+// Each cluster is probed with the credentials of a context that references it, preferring the current context.
+func ProbeClusters(config *clientcmdapi.Config, names []string, o Options) map[string]Result {
+	if o.Workers <= 0 {
+		o.Workers = DefaultWorkers
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = DefaultTimeout
+	}
+
+	results := make(map[string]Result, len(names))
+	lock := sync.Mutex{}
+	queue := make(chan string)
+	wg := sync.WaitGroup{}
+
+	for i := 0; i < o.Workers && i < len(names); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for name := range queue {
+				result := probeCluster(config, name, o)
+				lock.Lock()
+				results[name] = result
+				lock.Unlock()
+			}
+		}()
+	}
+
+	for _, name := range names {
+		queue <- name
+	}
+	close(queue)
+	wg.Wait()
+
+	return results
+}
+
+func probeCluster(config *clientcmdapi.Config, clusterName string, o Options) Result {
+	timeout := o.Timeout
+	cluster, ok := config.Clusters[clusterName]
+	if !ok || cluster == nil || len(cluster.Server) == 0 {
+		return Result{Healthz: StatusNoServer}
+	}
+
+	restConfig, err := restConfigFor(config, clusterName, o.Exec)
+	if err != nil {
+		return Result{Healthz: StatusInvalid}
+	}
+	restConfig.Timeout = timeout
+
+	transport, err := rest.TransportFor(restConfig)
+	if err != nil {
+		return Result{Healthz: StatusInvalid}
+	}
+	client := &http.Client{Transport: transport}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	result := Result{}
+	code, _, err := get(ctx, client, restConfig.Host, "/healthz")
+	if err != nil {
+		result.Healthz = classify(ctx, err)
+		return result
+	}
+	result.Healthz = fmt.Sprint(code)
+
+	if code, _, err = get(ctx, client, restConfig.Host, "/readyz"); err == nil {
+		result.Readyz = fmt.Sprint(code)
+	}
+
+	if code, body, err := get(ctx, client, restConfig.Host, "/version"); err == nil && code == http.StatusOK {
+		version := struct {
+			GitVersion string `json:"gitVersion"`
+		}{}
+		if json.Unmarshal(body, &version) == nil {
+			result.Version = version.GitVersion
+		}
+	}
+
+	return result
+}
+
+// restConfigFor builds a client config holding only the cluster and the user of the context chosen for it.
+// A user with an exec credential plugin is left out unless exec is set.
+func restConfigFor(config *clientcmdapi.Config, clusterName string, exec bool) (*rest.Config, error) {
+	probeConfig := clientcmdapi.NewConfig()
+	probeConfig.Clusters[clusterName] = config.Clusters[clusterName]
+
+	probeContext := clientcmdapi.NewContext()
+	probeContext.Cluster = clusterName
+	if context, ok := config.Contexts[contextFor(config, clusterName)]; ok {
+		if authInfo, ok := config.AuthInfos[context.AuthInfo]; ok && (exec || authInfo.Exec == nil) {
+			probeConfig.AuthInfos[context.AuthInfo] = authInfo
+			probeContext.AuthInfo = context.AuthInfo
+		}
+	}
+	probeConfig.Contexts[probeContextName] = probeContext
+	probeConfig.CurrentContext = probeContextName
+
+	return clientcmd.NewNonInteractiveClientConfig(*probeConfig, probeContextName, &clientcmd.ConfigOverrides{}, nil).ClientConfig()
+}
+
+// contextFor returns the current context if it references the cluster, otherwise the first context by name that does.
+func contextFor(config *clientcmdapi.Config, clusterName string) string {
+	if context, ok := config.Contexts[config.CurrentContext]; ok && context.Cluster == clusterName {
+		return config.CurrentContext
+	}
+
+	names := []string{}
+	for name, context := range config.Contexts {
+		if context.Cluster == clusterName {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	return names[0]
+}
+
+// get requests an endpoint of the server host, whose own path, like the /k8s/clusters/ID of a proxy, is kept.
+func get(ctx context.Context, client *http.Client, host, endpoint string) (int, []byte, error) {
+	u, err := url.Parse(host)
+	if err != nil {
+		return 0, nil, err
+	}
+	u.Path = path.Join("/", u.Path, endpoint)
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return resp.StatusCode, nil, nil
+	}
+	return resp.StatusCode, body, nil
+}
+
+func classify(ctx context.Context, err error) string {
+	if ctx.Err() == context.DeadlineExceeded {
+		return StatusTimeout
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return StatusTimeout
+	}
+
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var certificateInvalidErr x509.CertificateInvalidError
+	var recordHeaderErr tls.RecordHeaderError
+	if errors.As(err, &unknownAuthorityErr) || errors.As(err, &hostnameErr) ||
+		errors.As(err, &certificateInvalidErr) || errors.As(err, &recordHeaderErr) {
+		return StatusTLSError
+	}
+
+	return StatusUnreachable
+}
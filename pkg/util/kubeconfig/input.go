@@ -0,0 +1,200 @@
+package kubeconfig
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	// StdinFile is the file argument standing for the standard input.
+	StdinFile = "-"
+
+	// FlagFromBase64 is the flag of a kubeconfig given as a base64 string.
+	FlagFromBase64 = "from-base64"
+
+	// ReadOnlyAnnotation marks the commands, and the sub commands of the commands, which never write the
+	// kubeconfig, so that they accept a kubeconfig read from the standard input or from base64.
+	ReadOnlyAnnotation = "kubectl-cfg/read-only"
+
+	// ReadOnlyUnlessAnnotation marks a command which only writes the kubeconfig when the boolean flag named
+	// by its value is set, so that it accepts a kubeconfig read from the standard input or from base64 without it.
+	ReadOnlyUnlessAnnotation = "kubectl-cfg/read-only-unless"
+)
+
+// ReadInput reads a kubeconfig file, "-" standing for in. A base64 encoded kubeconfig is decoded.
+func ReadInput(file string, in io.Reader) ([]byte, error) {
+	var data []byte
+	var err error
+	if file == StdinFile {
+		data, err = ioutil.ReadAll(in)
+	} else {
+		data, err = ioutil.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if decoded, ok := decodeBase64(data); ok {
+		return decoded, nil
+	}
+	return data, nil
+}
+
+// LoadInput loads a kubeconfig file, "-" standing for in, with the relative paths resolved against the
+// directory of the file, or the working directory for the standard input.
+func LoadInput(file string, in io.Reader) (*clientcmdapi.Config, error) {
+	data, err := ReadInput(file, in)
+	if err != nil {
+		return nil, err
+	}
+	if file == StdinFile {
+		return loadData(data, "the standard input", "")
+	}
+	return loadData(data, file, filepath.Dir(file))
+}
+
+// LoadBase64 loads a kubeconfig given as a base64 string, with the relative paths resolved against the
+// working directory.
+func LoadBase64(value string) (*clientcmdapi.Config, error) {
+	data, ok := decodeBase64([]byte(value))
+	if !ok {
+		return nil, fmt.Errorf("--%s is not a base64 encoded kubeconfig", FlagFromBase64)
+	}
+	return loadData(data, "--"+FlagFromBase64, "")
+}
+
+// decodeBase64 decodes data when all of it is base64, line breaks included as 'base64' wraps its output.
+// A YAML or JSON kubeconfig is never valid base64 as it holds colons and spaces.
+func decodeBase64(data []byte) ([]byte, bool) {
+	trimmed := bytes.Join(bytes.Fields(data), nil)
+	if len(trimmed) == 0 {
+		return nil, false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(string(trimmed))
+	if err != nil {
+		return nil, false
+	}
+	return decoded, true
+}
+
+// loadData loads a kubeconfig, its relative paths being resolved against dir.
+func loadData(data []byte, source, dir string) (*clientcmdapi.Config, error) {
+	config, err := clientcmd.Load(data)
+	if err != nil {
+		return nil, fmt.Errorf("error reading the kubeconfig of %s: %v", source, err)
+	}
+
+	base, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, cluster := range config.Clusters {
+		if err := clientcmd.ResolvePaths(clientcmd.GetClusterFileReferences(cluster), base); err != nil {
+			return nil, err
+		}
+	}
+	for _, authInfo := range config.AuthInfos {
+		if err := clientcmd.ResolvePaths(clientcmd.GetAuthInfoFileReferences(authInfo), base); err != nil {
+			return nil, err
+		}
+	}
+	return config, nil
+}
+
+// InputConfigAccess is the ConfigAccess of the commands. It serves the loading precedence, or the
+// kubeconfig read from the standard input with --kubeconfig=- or given with --from-base64 once Load
+// is called.
+type InputConfigAccess struct {
+	*clientcmd.PathOptions
+	Base64 string
+
+	config *clientcmdapi.Config
+}
+
+// NewInputConfigAccess returns an InputConfigAccess serving the loading precedence of pathOptions until Load is called.
+func NewInputConfigAccess(pathOptions *clientcmd.PathOptions) *InputConfigAccess {
+	return &InputConfigAccess{PathOptions: pathOptions}
+}
+
+// Load reads the kubeconfig of --kubeconfig=- or --from-base64, if any, which only read only commands accept.
+func (a *InputConfigAccess) Load(cmd *cobra.Command, in io.Reader) error {
+	fromStdin := a.LoadingRules.ExplicitPath == StdinFile
+	if !fromStdin && len(a.Base64) == 0 {
+		return nil
+	}
+	if fromStdin && len(a.Base64) != 0 {
+		return fmt.Errorf("--%s and --%s=- cannot be used together", FlagFromBase64, a.ExplicitFileFlag)
+	}
+	if !isReadOnly(cmd) {
+		command := cmd.CommandPath()
+		if flag, ok := cmd.Annotations[ReadOnlyUnlessAnnotation]; ok {
+			command += " --" + flag
+		}
+		return fmt.Errorf("%q writes the kubeconfig, it cannot read it from the standard input or from --%s", command, FlagFromBase64)
+	}
+
+	var err error
+	if fromStdin {
+		a.config, err = LoadInput(StdinFile, in)
+	} else {
+		a.config, err = LoadBase64(a.Base64)
+	}
+	return err
+}
+
+// IsInput tells whether the kubeconfig is read from the standard input or from base64.
+func (a *InputConfigAccess) IsInput() bool {
+	return a.config != nil
+}
+
+// GetStartingConfig returns the kubeconfig read by Load, or the merge of the loading precedence.
+func (a *InputConfigAccess) GetStartingConfig() (*clientcmdapi.Config, error) {
+	if a.config != nil {
+		return a.config.DeepCopy(), nil
+	}
+	return a.PathOptions.GetStartingConfig()
+}
+
+// GetLoadingPrecedence returns no file for the kubeconfig read by Load, so that nothing is locked or written.
+func (a *InputConfigAccess) GetLoadingPrecedence() []string {
+	if a.config != nil {
+		return []string{}
+	}
+	return a.PathOptions.GetLoadingPrecedence()
+}
+
+// GetDefaultFilename refuses to name a file for the kubeconfig read by Load.
+func (a *InputConfigAccess) GetDefaultFilename() string {
+	if a.config != nil {
+		return ""
+	}
+	return a.PathOptions.GetDefaultFilename()
+}
+
+// IsExplicitFile is false for the kubeconfig read by Load.
+func (a *InputConfigAccess) IsExplicitFile() bool {
+	if a.config != nil {
+		return false
+	}
+	return a.PathOptions.IsExplicitFile()
+}
+
+func isReadOnly(cmd *cobra.Command) bool {
+	if flag, ok := cmd.Annotations[ReadOnlyUnlessAnnotation]; ok {
+		f := cmd.Flags().Lookup(flag)
+		return f == nil || f.Value.String() != "true"
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		if _, ok := c.Annotations[ReadOnlyAnnotation]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,291 @@
+package kubeconfig
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/it2911/kubectl-cfg/pkg/util/cert"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const (
+	AuthMethodNone         = "none"
+	AuthMethodToken        = "token"
+	AuthMethodTokenFile    = "token-file"
+	AuthMethodClientCert   = "client-cert"
+	AuthMethodBasic        = "basic"
+	AuthMethodExec         = "exec"
+	AuthMethodAuthProvider = "auth-provider"
+)
+
+// AuthMethod detects how the auth info authenticates against the API server.
+// Exec plugins and auth providers take precedence as kubectl prefers them over static credentials.
+func AuthMethod(authInfo *clientcmdapi.AuthInfo) string {
+	switch {
+	case authInfo == nil:
+		return AuthMethodNone
+	case authInfo.Exec != nil:
+		return AuthMethodExec
+	case authInfo.AuthProvider != nil:
+		return AuthMethodAuthProvider + ":" + authInfo.AuthProvider.Name
+	case len(authInfo.Token) != 0:
+		return AuthMethodToken
+	case len(authInfo.TokenFile) != 0:
+		return AuthMethodTokenFile
+	case len(authInfo.ClientCertificate) != 0 || len(authInfo.ClientCertificateData) != 0:
+		return AuthMethodClientCert
+	case len(authInfo.Username) != 0 || len(authInfo.Password) != 0:
+		return AuthMethodBasic
+	}
+	return AuthMethodNone
+}
+
+// ExecCommand returns the command of the exec credential plugin of the auth info, if any.
+func ExecCommand(authInfo *clientcmdapi.AuthInfo) string {
+	if authInfo == nil || authInfo.Exec == nil {
+		return ""
+	}
+	return authInfo.Exec.Command
+}
+
+// FreeName returns the first name-N which is not taken.
+func FreeName(name string, taken func(string) bool) string {
+	for n := 1; ; n++ {
+		candidate := fmt.Sprintf("%s-%d", name, n)
+		if !taken(candidate) {
+			return candidate
+		}
+	}
+}
+
+// ContextNames returns the sorted names of the contexts.
+func ContextNames(contexts map[string]*clientcmdapi.Context) []string {
+	names := []string{}
+	for name := range contexts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ClusterNames returns the sorted names of the clusters.
+func ClusterNames(clusters map[string]*clientcmdapi.Cluster) []string {
+	names := []string{}
+	for name := range clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// AuthInfoNames returns the sorted names of the auth infos.
+func AuthInfoNames(authInfos map[string]*clientcmdapi.AuthInfo) []string {
+	names := []string{}
+	for name := range authInfos {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ContextsUsingCluster returns the sorted names of the contexts which reference the cluster.
+func ContextsUsingCluster(config *clientcmdapi.Config, clusterName string) []string {
+	names := []string{}
+	for name, context := range config.Contexts {
+		if context.Cluster == clusterName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ContextsUsingAuthInfo returns the sorted names of the contexts which reference the auth info.
+func ContextsUsingAuthInfo(config *clientcmdapi.Config, authInfoName string) []string {
+	names := []string{}
+	for name, context := range config.Contexts {
+		if context.AuthInfo == authInfoName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MissingReferences returns the cluster and the user a context references which don't exist, like
+// `cluster "name"`. An empty reference is not missing, as a context of a cluster without authentication
+// has no user.
+func MissingReferences(config *clientcmdapi.Config, context *clientcmdapi.Context) []string {
+	missing := []string{}
+	if _, ok := config.Clusters[context.Cluster]; len(context.Cluster) != 0 && !ok {
+		missing = append(missing, fmt.Sprintf("cluster %q", context.Cluster))
+	}
+	if _, ok := config.AuthInfos[context.AuthInfo]; len(context.AuthInfo) != 0 && !ok {
+		missing = append(missing, fmt.Sprintf("user %q", context.AuthInfo))
+	}
+	return missing
+}
+
+// IsOrphanedCluster tells whether no context references the cluster, the contexts of ignored aside.
+func IsOrphanedCluster(config *clientcmdapi.Config, clusterName string, ignored map[string]bool) bool {
+	for _, name := range ContextsUsingCluster(config, clusterName) {
+		if !ignored[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// IsOrphanedAuthInfo tells whether no context references the auth info, the contexts of ignored aside.
+func IsOrphanedAuthInfo(config *clientcmdapi.Config, authInfoName string, ignored map[string]bool) bool {
+	for _, name := range ContextsUsingAuthInfo(config, authInfoName) {
+		if !ignored[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// ClustersUsedByAuthInfo returns the sorted names of the clusters the auth info is used with.
+func ClustersUsedByAuthInfo(config *clientcmdapi.Config, authInfoName string) []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, context := range config.Contexts {
+		if context.AuthInfo == authInfoName && len(context.Cluster) != 0 && !seen[context.Cluster] {
+			seen[context.Cluster] = true
+			names = append(names, context.Cluster)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ExtensionNames returns the sorted names of the extensions.
+func ExtensionNames(extensions map[string]runtime.Object) []string {
+	names := []string{}
+	for name := range extensions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Credential is the identity and expiry found in the static credentials of an auth info.
+type Credential struct {
+	Subject string
+	Groups  []string
+	// NotAfter is zero when the credentials carry no expiry.
+	NotAfter time.Time
+}
+
+// AuthInfoCredential decodes the client certificate, the JWT bearer token or the basic auth
+// username of the auth info. Exec plugins and auth providers yield an empty Credential.
+func AuthInfoCredential(authInfo *clientcmdapi.AuthInfo) (Credential, error) {
+	credential := Credential{}
+	if authInfo == nil {
+		return credential, nil
+	}
+
+	switch AuthMethod(authInfo) {
+	case AuthMethodClientCert:
+		certs, err := cert.LoadCertificates(authInfo.ClientCertificateData, authInfo.ClientCertificate)
+		if err != nil {
+			return credential, err
+		}
+		if len(certs) == 0 {
+			return credential, fmt.Errorf("no client certificate found")
+		}
+		credential.Subject = certs[0].Subject.CommonName
+		credential.Groups = certs[0].Subject.Organization
+		credential.NotAfter = certs[0].NotAfter
+	case AuthMethodToken, AuthMethodTokenFile:
+		token := authInfo.Token
+		if len(token) == 0 {
+			data, err := ioutil.ReadFile(authInfo.TokenFile)
+			if err != nil {
+				return credential, err
+			}
+			token = strings.TrimSpace(string(data))
+		}
+		claims, ok := JWTClaims(token)
+		if !ok {
+			return credential, nil
+		}
+		credential.Subject = claims.Subject
+		if claims.Expiry != 0 {
+			credential.NotAfter = time.Unix(int64(claims.Expiry), 0)
+		}
+	case AuthMethodBasic:
+		credential.Subject = authInfo.Username
+	}
+	return credential, nil
+}
+
+// TokenClaims are the claims of a JWT token which tell whom it authenticates and until when.
+type TokenClaims struct {
+	Subject string  `json:"sub"`
+	Expiry  float64 `json:"exp"`
+}
+
+// JWTClaims decodes the payload of a JWT without verifying its signature.
+// It returns false when the token is opaque.
+func JWTClaims(token string) (TokenClaims, bool) {
+	claims := TokenClaims{}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return claims, false
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return claims, false
+	}
+	return claims, true
+}
+
+// CurrentContext returns the current context, or an empty context when current-context is
+// not set or names a context which does not exist.
+func CurrentContext(config *clientcmdapi.Config) *clientcmdapi.Context {
+	if context, ok := config.Contexts[config.CurrentContext]; ok && context != nil {
+		return context
+	}
+	return clientcmdapi.NewContext()
+}
+
+// FileConfig is one file of the loading precedence with its own content.
+type FileConfig struct {
+	File   string
+	Config *clientcmdapi.Config
+}
+
+// LoadFiles loads every existing file of the loading precedence on its own, in precedence order.
+// The merged config returned by GetStartingConfig takes each entry from the first file defining it.
+func LoadFiles(configAccess clientcmd.ConfigAccess) ([]FileConfig, error) {
+	files := configAccess.GetLoadingPrecedence()
+	if configAccess.IsExplicitFile() {
+		files = []string{configAccess.GetExplicitFile()}
+	}
+
+	fileConfigs := []FileConfig{}
+	for _, file := range files {
+		config, err := clientcmd.LoadFromFile(file)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		fileConfigs = append(fileConfigs, FileConfig{File: file, Config: config})
+	}
+	return fileConfigs, nil
+}
@@ -0,0 +1,102 @@
+package kubeconfig
+
+import (
+	"reflect"
+	"testing"
+
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestCurrentContext(t *testing.T) {
+	current := &clientcmdapi.Context{Cluster: "c", AuthInfo: "u"}
+	tests := []struct {
+		name           string
+		currentContext string
+		contexts       map[string]*clientcmdapi.Context
+		expected       clientcmdapi.Context
+	}{
+		{
+			name:           "current context",
+			currentContext: "a",
+			contexts:       map[string]*clientcmdapi.Context{"a": current},
+			expected:       *current,
+		},
+		{
+			name:     "current-context not set",
+			contexts: map[string]*clientcmdapi.Context{"a": current},
+		},
+		{
+			name:           "current-context names a missing context",
+			currentContext: "gone",
+			contexts:       map[string]*clientcmdapi.Context{"a": current},
+		},
+		{
+			name:           "current-context names a nil context",
+			currentContext: "a",
+			contexts:       map[string]*clientcmdapi.Context{"a": nil},
+		},
+	}
+
+	for _, test := range tests {
+		config := clientcmdapi.NewConfig()
+		config.CurrentContext = test.currentContext
+		config.Contexts = test.contexts
+
+		context := CurrentContext(config)
+		if context == nil {
+			t.Errorf("%s: expected a context, got nil", test.name)
+			continue
+		}
+		if context.Cluster != test.expected.Cluster || context.AuthInfo != test.expected.AuthInfo {
+			t.Errorf("%s: expected cluster %q and user %q, got %q and %q", test.name, test.expected.Cluster, test.expected.AuthInfo, context.Cluster, context.AuthInfo)
+		}
+	}
+}
+
+func TestMissingReferences(t *testing.T) {
+	config := clientcmdapi.NewConfig()
+	config.Clusters["c"] = clientcmdapi.NewCluster()
+	config.AuthInfos["u"] = clientcmdapi.NewAuthInfo()
+
+	tests := []struct {
+		name     string
+		context  clientcmdapi.Context
+		expected []string
+	}{
+		{name: "resolved", context: clientcmdapi.Context{Cluster: "c", AuthInfo: "u"}, expected: []string{}},
+		{name: "no user", context: clientcmdapi.Context{Cluster: "c"}, expected: []string{}},
+		{name: "no cluster and no user", context: clientcmdapi.Context{}, expected: []string{}},
+		{name: "missing user", context: clientcmdapi.Context{Cluster: "c", AuthInfo: "gone"}, expected: []string{`user "gone"`}},
+		{name: "missing both", context: clientcmdapi.Context{Cluster: "x", AuthInfo: "y"}, expected: []string{`cluster "x"`, `user "y"`}},
+	}
+
+	for _, test := range tests {
+		missing := MissingReferences(config, &test.context)
+		if !reflect.DeepEqual(missing, test.expected) {
+			t.Errorf("%s: expected %v, got %v", test.name, test.expected, missing)
+		}
+	}
+}
+
+func TestIsOrphaned(t *testing.T) {
+	config := clientcmdapi.NewConfig()
+	config.Clusters["used"] = clientcmdapi.NewCluster()
+	config.Clusters["broken-only"] = clientcmdapi.NewCluster()
+	config.Clusters["unused"] = clientcmdapi.NewCluster()
+	config.AuthInfos["u"] = clientcmdapi.NewAuthInfo()
+	config.Contexts["a"] = &clientcmdapi.Context{Cluster: "used", AuthInfo: "u"}
+	config.Contexts["b"] = &clientcmdapi.Context{Cluster: "broken-only", AuthInfo: "u"}
+	ignored := map[string]bool{"b": true}
+
+	for name, expected := range map[string]bool{"used": false, "broken-only": true, "unused": true} {
+		if orphaned := IsOrphanedCluster(config, name, ignored); orphaned != expected {
+			t.Errorf("cluster %s: expected orphaned %v, got %v", name, expected, orphaned)
+		}
+	}
+	if IsOrphanedAuthInfo(config, "u", ignored) {
+		t.Errorf("user u: expected to be referenced by context a")
+	}
+	if !IsOrphanedAuthInfo(config, "u", map[string]bool{"a": true, "b": true}) {
+		t.Errorf("user u: expected to be orphaned once a and b are ignored")
+	}
+}
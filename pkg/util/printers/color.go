@@ -0,0 +1,143 @@
+package printers
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/it2911/kubectl-cfg/pkg/util/settings"
+	"github.com/logrusorgru/aurora"
+	"k8s.io/kubectl/pkg/util/term"
+)
+
+const (
+	ColorAuto   = "auto"
+	ColorAlways = "always"
+	ColorNever  = "never"
+
+	// EnvNoColor disables colors in auto mode when it is set, see https://no-color.org.
+	EnvNoColor = "NO_COLOR"
+)
+
+var colorNames = map[string]aurora.Color{
+	"black":   aurora.BlackFg,
+	"red":     aurora.RedFg,
+	"green":   aurora.GreenFg,
+	"yellow":  aurora.YellowFg,
+	"blue":    aurora.BlueFg,
+	"magenta": aurora.MagentaFg,
+	"cyan":    aurora.CyanFg,
+	"white":   aurora.WhiteFg,
+
+	"bright":    aurora.BrightFg,
+	"bold":      aurora.BoldFm,
+	"faint":     aurora.FaintFm,
+	"italic":    aurora.ItalicFm,
+	"underline": aurora.UnderlineFm,
+	"reverse":   aurora.ReverseFm,
+}
+
+// ParseColor parses a color made of space separated words, e.g. "red", "bold green" or "bright yellow".
+// "none" and the empty string disable the color.
+func ParseColor(spec string) (aurora.Color, error) {
+	var color aurora.Color
+	for _, word := range strings.Fields(strings.ToLower(spec)) {
+		if word == "none" {
+			continue
+		}
+		c, ok := colorNames[word]
+		if !ok {
+			return 0, fmt.Errorf("unknown color %q in %q", word, spec)
+		}
+		color |= c
+	}
+	return color, nil
+}
+
+// ColorEnabled tells whether colors are written to out in the given mode. In auto mode, colors are
+// only written to a terminal and never when the NO_COLOR environment variable is set.
+func ColorEnabled(mode string, out io.Writer) (bool, error) {
+	switch mode {
+	case ColorAlways:
+		return true, nil
+	case ColorNever:
+		return false, nil
+	case ColorAuto, "":
+		if len(os.Getenv(EnvNoColor)) != 0 {
+			return false, nil
+		}
+		return term.IsTerminal(out), nil
+	}
+	return false, fmt.Errorf("color must be one of %s, %s or %s: %v", ColorAuto, ColorAlways, ColorNever, mode)
+}
+
+// Colorizer paints the cells of a table with the colors of a theme, or leaves them untouched when
+// colors are disabled.
+type Colorizer struct {
+	enabled bool
+
+	current     aurora.Color
+	expired     aurora.Color
+	expiring    aurora.Color
+	unreachable aurora.Color
+	production  aurora.Color
+}
+
+// NewColorizer returns a Colorizer for out, using the colors of the theme.
+func NewColorizer(mode string, out io.Writer, theme settings.Theme) (*Colorizer, error) {
+	enabled, err := ColorEnabled(mode, out)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Colorizer{enabled: enabled}
+	for _, color := range []struct {
+		name  string
+		spec  string
+		value *aurora.Color
+	}{
+		{"current", theme.Current, &c.current},
+		{"expired", theme.Expired, &c.expired},
+		{"expiring", theme.Expiring, &c.expiring},
+		{"unreachable", theme.Unreachable, &c.unreachable},
+		{"production", theme.Production, &c.production},
+	} {
+		if *color.value, err = ParseColor(color.spec); err != nil {
+			return nil, fmt.Errorf("invalid %s color of the theme: %v", color.name, err)
+		}
+	}
+	return c, nil
+}
+
+// Current paints a cell of the current entry.
+func (c *Colorizer) Current(cell interface{}) interface{} {
+	return c.paint(cell, c.current)
+}
+
+// Expired paints an expired or invalid credential.
+func (c *Colorizer) Expired(cell interface{}) interface{} {
+	return c.paint(cell, c.expired)
+}
+
+// Expiring paints a credential which expires soon.
+func (c *Colorizer) Expiring(cell interface{}) interface{} {
+	return c.paint(cell, c.expiring)
+}
+
+// Unreachable paints the status of a cluster which did not answer the probe.
+func (c *Colorizer) Unreachable(cell interface{}) interface{} {
+	return c.paint(cell, c.unreachable)
+}
+
+// Production paints a cell of a production entry.
+func (c *Colorizer) Production(cell interface{}) interface{} {
+	return c.paint(cell, c.production)
+}
+
+func (c *Colorizer) paint(cell interface{}, color aurora.Color) interface{} {
+	if c == nil || !c.enabled || color == 0 {
+		return cell
+	}
+	return aurora.Colorize(cell, color)
+}
@@ -0,0 +1,160 @@
+package printers
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/util/jsonpath"
+)
+
+var jsonRegexp = regexp.MustCompile(`^\{\.?([^{}]+)\}$|^\.?([^{}]+)$`)
+
+// Column is a single column of the custom-columns output: a header and the JSONPath of its value.
+type Column struct {
+	Header    string
+	FieldSpec string
+}
+
+// CustomColumnsPrinter prints the items of a list as a table whose columns are described by JSONPath expressions.
+type CustomColumnsPrinter struct {
+	Columns   []Column
+	NoHeaders bool
+}
+
+// NewCustomColumnsPrinterFromSpec creates a CustomColumnsPrinter from a spec like "NAME:.name,SERVER:.server".
+func NewCustomColumnsPrinterFromSpec(spec string, noHeaders bool) (*CustomColumnsPrinter, error) {
+	if len(spec) == 0 {
+		return nil, fmt.Errorf("custom-columns format specified but no custom columns given")
+	}
+
+	parts := strings.Split(spec, ",")
+	columns := make([]Column, len(parts))
+	for ix := range parts {
+		colSpec := strings.SplitN(parts[ix], ":", 2)
+		if len(colSpec) != 2 {
+			return nil, fmt.Errorf("unexpected custom-columns spec: %s, expected <header>:<json-path-expr>", parts[ix])
+		}
+		fieldSpec, err := RelaxedJSONPathExpression(colSpec[1])
+		if err != nil {
+			return nil, err
+		}
+		columns[ix] = Column{Header: colSpec[0], FieldSpec: fieldSpec}
+	}
+	return &CustomColumnsPrinter{Columns: columns, NoHeaders: noHeaders}, nil
+}
+
+// NewCustomColumnsPrinterFromFile creates a CustomColumnsPrinter from a file holding the headers on
+// its first line and the matching JSONPath expressions on its second line.
+func NewCustomColumnsPrinterFromFile(file string, noHeaders bool) (*CustomColumnsPrinter, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("error reading template %s, %v", file, err)
+	}
+
+	scanner := bufio.NewScanner(bytes.NewBuffer(data))
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("invalid template %s, missing header line", file)
+	}
+	headers := strings.Fields(scanner.Text())
+	if !scanner.Scan() {
+		return nil, fmt.Errorf("invalid template %s, missing spec line", file)
+	}
+	specs := strings.Fields(scanner.Text())
+	if len(headers) != len(specs) {
+		return nil, fmt.Errorf("number of headers (%d) and field specifications (%d) don't match", len(headers), len(specs))
+	}
+
+	columns := make([]Column, len(headers))
+	for ix := range headers {
+		fieldSpec, err := RelaxedJSONPathExpression(specs[ix])
+		if err != nil {
+			return nil, err
+		}
+		columns[ix] = Column{Header: headers[ix], FieldSpec: fieldSpec}
+	}
+	return &CustomColumnsPrinter{Columns: columns, NoHeaders: noHeaders}, nil
+}
+
+// PrintObj prints every item of an unstructured list, or the object itself when it is not a list.
+func (p *CustomColumnsPrinter) PrintObj(obj runtime.Object, out io.Writer) error {
+	u, ok := obj.(runtime.Unstructured)
+	if !ok {
+		return fmt.Errorf("custom-columns output is not supported for %T", obj)
+	}
+
+	parsers := make([]*jsonpath.JSONPath, len(p.Columns))
+	for ix := range p.Columns {
+		parsers[ix] = jsonpath.New(fmt.Sprintf("column%d", ix)).AllowMissingKeys(true)
+		if err := parsers[ix].Parse(p.Columns[ix].FieldSpec); err != nil {
+			return err
+		}
+	}
+
+	w := GetNewTabWriter(out)
+	defer w.Flush()
+
+	if !p.NoHeaders {
+		headers := make([]string, len(p.Columns))
+		for ix := range p.Columns {
+			headers[ix] = p.Columns[ix].Header
+		}
+		fmt.Fprintln(w, strings.Join(headers, "\t"))
+	}
+
+	content := u.UnstructuredContent()
+	items, isList := content["items"].([]interface{})
+	if !isList {
+		items = []interface{}{content}
+	}
+
+	for _, item := range items {
+		columns := make([]string, len(parsers))
+		for ix, parser := range parsers {
+			values, err := parser.FindResults(item)
+			if err != nil {
+				return err
+			}
+			valueStrings := []string{}
+			for arrIx := range values {
+				for valIx := range values[arrIx] {
+					valueStrings = append(valueStrings, fmt.Sprintf("%v", values[arrIx][valIx].Interface()))
+				}
+			}
+			columns[ix] = strings.Join(valueStrings, ",")
+			if len(columns[ix]) == 0 {
+				columns[ix] = "<none>"
+			}
+		}
+		if _, err := fmt.Fprintln(w, strings.Join(columns, "\t")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RelaxedJSONPathExpression turns ".name" and "name" into the "{.name}" form the jsonpath package expects.
+func RelaxedJSONPathExpression(pathExpression string) (string, error) {
+	if len(pathExpression) == 0 {
+		return pathExpression, nil
+	}
+	submatches := jsonRegexp.FindStringSubmatch(pathExpression)
+	if submatches == nil {
+		return "", fmt.Errorf("unexpected path string, expected a 'name1.name2' or '.name1.name2' or '{name1.name2}' or '{.name1.name2}'")
+	}
+	if len(submatches) != 3 {
+		return "", fmt.Errorf("unexpected submatch list: %v", submatches)
+	}
+	var fieldSpec string
+	if len(submatches[1]) != 0 {
+		fieldSpec = submatches[1]
+	} else {
+		fieldSpec = submatches[2]
+	}
+	return fmt.Sprintf("{.%s}", fieldSpec), nil
+}
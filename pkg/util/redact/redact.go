@@ -0,0 +1,96 @@
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Redacted replaces the secrets in every output unless --show-secrets is given.
+const Redacted = "REDACTED"
+
+// redactedBytes is printed as REDACTED once base64 encoded by the kubeconfig serializer, the same
+// trick clientcmdapi.ShortenConfig uses for the certificate and key data.
+var redactedBytes, _ = base64.StdEncoding.DecodeString(Redacted + "+")
+
+// secretKeyParts are the substrings which mark a key of an auth provider config as secret, e.g.
+// id-token, refresh-token, access-token or client-secret.
+var secretKeyParts = []string{"token", "secret", "password", "key"}
+
+// Value masks a secret with a short fingerprint, so that two secrets can still be told apart.
+// Empty values stay empty, so that unset fields remain visibly unset.
+func Value(secret string) string {
+	if len(secret) == 0 {
+		return secret
+	}
+	sum := sha256.Sum256([]byte(secret))
+	return fmt.Sprintf("%s (sha256:%x)", Redacted, sum[:4])
+}
+
+// IsSecretKey tells whether the value of an auth provider config key is a secret.
+func IsSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, part := range secretKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthProviderConfig returns a copy of an auth provider config whose secret values are masked.
+func AuthProviderConfig(config map[string]string) map[string]string {
+	if config == nil {
+		return nil
+	}
+	masked := make(map[string]string, len(config))
+	for key, value := range config {
+		if IsSecretKey(key) {
+			value = Value(value)
+		}
+		masked[key] = value
+	}
+	return masked
+}
+
+// AuthInfo masks the token, password, client key, auth provider secrets and exec env values of the auth info in place.
+// It tells whether a secret was found.
+func AuthInfo(authInfo *clientcmdapi.AuthInfo) bool {
+	if authInfo == nil {
+		return false
+	}
+
+	masked := len(authInfo.Token) != 0 || len(authInfo.Password) != 0 || len(authInfo.ClientKeyData) != 0
+	authInfo.Token = Value(authInfo.Token)
+	authInfo.Password = Value(authInfo.Password)
+	if len(authInfo.ClientKeyData) != 0 {
+		authInfo.ClientKeyData = redactedBytes
+	}
+	if authInfo.AuthProvider != nil {
+		for key := range authInfo.AuthProvider.Config {
+			masked = masked || IsSecretKey(key)
+		}
+		authInfo.AuthProvider.Config = AuthProviderConfig(authInfo.AuthProvider.Config)
+	}
+	if authInfo.Exec != nil {
+		for ix := range authInfo.Exec.Env {
+			masked = true
+			authInfo.Exec.Env[ix].Value = Value(authInfo.Exec.Env[ix].Value)
+		}
+	}
+	return masked
+}
+
+// Config masks the secrets of every auth info of the config in place. It tells whether a secret was found.
+func Config(config *clientcmdapi.Config) bool {
+	masked := false
+	for _, authInfo := range config.AuthInfos {
+		if AuthInfo(authInfo) {
+			masked = true
+		}
+	}
+	return masked
+}
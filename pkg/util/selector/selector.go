@@ -0,0 +1,293 @@
+package selector
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/it2911/kubectl-cfg/pkg/util/kubeconfig"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// Kind is the kind of kubeconfig entry a Filter selects.
+type Kind string
+
+const (
+	Context  Kind = "context"
+	Cluster  Kind = "cluster"
+	AuthInfo Kind = "user"
+)
+
+// fieldNames are the fields each kind of entry can be selected by.
+var fieldNames = map[Kind]sets.String{
+	Context:  sets.NewString("name", "cluster", "user", "namespace", "server"),
+	Cluster:  sets.NewString("name", "server", "context", "user", "namespace"),
+	AuthInfo: sets.NewString("name", "user", "context", "cluster", "server", "namespace"),
+}
+
+// Pattern matches a name either as a glob, or as a regular expression when it is enclosed in slashes.
+// Unlike path.Match, the '*' of a glob also matches '/' so that it works with ARN-like names.
+type Pattern struct {
+	raw    string
+	regexp *regexp.Regexp
+}
+
+// ParsePattern parses a glob like "eks-*" or a regular expression like "/^eks-.*$/".
+func ParsePattern(pattern string) (Pattern, error) {
+	expr := ""
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		expr = pattern[1 : len(pattern)-1]
+	} else {
+		expr = globToRegexp(pattern)
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return Pattern{}, fmt.Errorf("invalid pattern %q: %v", pattern, err)
+	}
+	return Pattern{raw: pattern, regexp: re}, nil
+}
+
+// Match tells whether the value matches the pattern.
+func (p Pattern) Match(value string) bool {
+	return p.regexp.MatchString(value)
+}
+
+// String returns the pattern as it was given.
+func (p Pattern) String() string {
+	return p.raw
+}
+
+// globToRegexp translates the '*', '?' and '[...]' wildcards of a glob into an anchored regular expression.
+func globToRegexp(glob string) string {
+	expr := strings.Builder{}
+	expr.WriteString("^")
+	inClass := false
+	for _, r := range glob {
+		switch {
+		case inClass:
+			if r == ']' {
+				inClass = false
+			}
+			expr.WriteRune(r)
+		case r == '*':
+			expr.WriteString(".*")
+		case r == '?':
+			expr.WriteString(".")
+		case r == '[':
+			inClass = true
+			expr.WriteRune(r)
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+	return expr.String()
+}
+
+type requirement struct {
+	field   string
+	pattern Pattern
+	negate  bool
+}
+
+// Options holds the filters shared by the commands which select entries of a kubeconfig.
+type Options struct {
+	Selector      string
+	ServerMatches string
+	Namespace     string
+	User          string
+}
+
+// AddFlags binds the filter flags to the command.
+func (o *Options) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.Selector, "selector", "l", o.Selector, "Selector to filter on, supports '=', '==' and '!=' with glob or /regex/ values (e.g. -l cluster=prod-*,namespace!=default). Matching objects must satisfy all of the specified requirements.")
+	cmd.Flags().StringVar(&o.ServerMatches, "server-matches", o.ServerMatches, "Only select the entries whose server matches this regular expression")
+	cmd.Flags().StringVar(&o.Namespace, "namespace", o.Namespace, "Only select the entries used with a namespace matching this glob or /regex/")
+	cmd.Flags().StringVar(&o.User, "user", o.User, "Only select the entries used with a user matching this glob or /regex/")
+}
+
+// ToFilter validates the options and returns the Filter selecting entries of kind whose names match one of the patterns.
+// Every entry matches when no pattern is given.
+func (o *Options) ToFilter(kind Kind, patterns []string) (*Filter, error) {
+	f := &Filter{kind: kind}
+
+	for _, p := range patterns {
+		pattern, err := ParsePattern(p)
+		if err != nil {
+			return nil, err
+		}
+		f.names = append(f.names, pattern)
+	}
+
+	if len(o.Selector) != 0 {
+		for _, term := range strings.Split(o.Selector, ",") {
+			r, err := parseRequirement(kind, strings.TrimSpace(term))
+			if err != nil {
+				return nil, err
+			}
+			f.requirements = append(f.requirements, r)
+		}
+	}
+
+	if len(o.ServerMatches) != 0 {
+		re, err := regexp.Compile(o.ServerMatches)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --server-matches %q: %v", o.ServerMatches, err)
+		}
+		f.requirements = append(f.requirements, requirement{field: "server", pattern: Pattern{raw: o.ServerMatches, regexp: re}})
+	}
+
+	for field, value := range map[string]string{"namespace": o.Namespace, "user": o.User} {
+		if len(value) == 0 {
+			continue
+		}
+		pattern, err := ParsePattern(value)
+		if err != nil {
+			return nil, err
+		}
+		f.requirements = append(f.requirements, requirement{field: field, pattern: pattern})
+	}
+
+	return f, nil
+}
+
+func parseRequirement(kind Kind, term string) (requirement, error) {
+	r := requirement{}
+	var parts []string
+	switch {
+	case strings.Contains(term, "!="):
+		parts = strings.SplitN(term, "!=", 2)
+		r.negate = true
+	case strings.Contains(term, "=="):
+		parts = strings.SplitN(term, "==", 2)
+	case strings.Contains(term, "="):
+		parts = strings.SplitN(term, "=", 2)
+	default:
+		return r, fmt.Errorf("invalid selector %q, expected <field>=<value> or <field>!=<value>", term)
+	}
+
+	r.field = strings.TrimSpace(parts[0])
+	if !fieldNames[kind].Has(r.field) {
+		return r, fmt.Errorf("invalid selector field %q for %s, must be one of: %s", r.field, kind, strings.Join(fieldNames[kind].List(), ", "))
+	}
+
+	pattern, err := ParsePattern(strings.TrimSpace(parts[1]))
+	if err != nil {
+		return r, err
+	}
+	r.pattern = pattern
+	return r, nil
+}
+
+// Filter selects the entries of one kind of a kubeconfig by name patterns and field requirements.
+type Filter struct {
+	kind         Kind
+	names        []Pattern
+	requirements []requirement
+}
+
+// Select returns the sorted names of the entries which match the filter.
+// An error is returned for each name pattern which matches no entry at all.
+func (f *Filter) Select(config *clientcmdapi.Config) ([]string, []error) {
+	allNames := entryNames(config, f.kind)
+
+	selected := sets.NewString()
+	allErrs := []error{}
+	if len(f.names) == 0 {
+		selected.Insert(allNames...)
+	} else {
+		for _, pattern := range f.names {
+			found := false
+			for _, name := range allNames {
+				if pattern.Match(name) {
+					selected.Insert(name)
+					found = true
+				}
+			}
+			if !found {
+				allErrs = append(allErrs, fmt.Errorf("%s %v not found", f.kind, pattern))
+			}
+		}
+	}
+
+	names := []string{}
+	for _, name := range selected.List() {
+		if f.matches(Fields(config, f.kind, name)) {
+			names = append(names, name)
+		}
+	}
+	return names, allErrs
+}
+
+// HasRequirements tells whether the filter selects by anything else than the names.
+func (f *Filter) HasRequirements() bool {
+	return len(f.requirements) != 0
+}
+
+func (f *Filter) matches(fields map[string][]string) bool {
+	for _, r := range f.requirements {
+		matched := false
+		for _, value := range fields[r.field] {
+			if r.pattern.Match(value) {
+				matched = true
+				break
+			}
+		}
+		if matched == r.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// Fields returns the values of the selectable fields of an entry. Clusters and users also carry the
+// fields of the contexts which reference them.
+func Fields(config *clientcmdapi.Config, kind Kind, name string) map[string][]string {
+	fields := map[string][]string{"name": {name}}
+
+	switch kind {
+	case Context:
+		context := config.Contexts[name]
+		fields["cluster"] = []string{context.Cluster}
+		fields["user"] = []string{context.AuthInfo}
+		fields["namespace"] = []string{context.Namespace}
+		if cluster, ok := config.Clusters[context.Cluster]; ok {
+			fields["server"] = []string{cluster.Server}
+		}
+	case Cluster:
+		fields["server"] = []string{config.Clusters[name].Server}
+		for _, contextName := range kubeconfig.ContextsUsingCluster(config, name) {
+			context := config.Contexts[contextName]
+			fields["context"] = append(fields["context"], contextName)
+			fields["user"] = append(fields["user"], context.AuthInfo)
+			fields["namespace"] = append(fields["namespace"], context.Namespace)
+		}
+	case AuthInfo:
+		fields["user"] = []string{name}
+		for _, contextName := range kubeconfig.ContextsUsingAuthInfo(config, name) {
+			context := config.Contexts[contextName]
+			fields["context"] = append(fields["context"], contextName)
+			fields["cluster"] = append(fields["cluster"], context.Cluster)
+			fields["namespace"] = append(fields["namespace"], context.Namespace)
+			if cluster, ok := config.Clusters[context.Cluster]; ok {
+				fields["server"] = append(fields["server"], cluster.Server)
+			}
+		}
+	}
+	return fields
+}
+
+func entryNames(config *clientcmdapi.Config, kind Kind) []string {
+	switch kind {
+	case Context:
+		return kubeconfig.ContextNames(config.Contexts)
+	case Cluster:
+		return kubeconfig.ClusterNames(config.Clusters)
+	case AuthInfo:
+		return kubeconfig.AuthInfoNames(config.AuthInfos)
+	}
+	return []string{}
+}
@@ -0,0 +1,190 @@
+package settings
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+const (
+	// EnvSettings overrides the location of the settings file.
+	EnvSettings = "KUBECTL_CFG_SETTINGS"
+
+	// EnvBackupDir overrides the backup directory of the settings file.
+	EnvBackupDir = "KUBECTL_CFG_BACKUP_DIR"
+
+	settingsFileName = "kubectl-cfg.yaml"
+)
+
+// Settings are the user preferences of kubectl-cfg, read from ~/.kube/kubectl-cfg.yaml.
+//
+//	color: auto
+//	theme:
+//	  current: green
+//	  expired: red
+//	  expiring: yellow
+//	  unreachable: red
+//	  production: bold magenta
+//	production:
+//	- prod-*
+//	- /.*-prd$/
+//	backup:
+//	  dir: /var/backups/kube
+//	  maxCount: 100
+//	  maxSize: 10Mi
+//	  maxAge: 90d
+type Settings struct {
+	// Color is the default of the --color flag.
+	Color string `yaml:"color,omitempty"`
+	Theme Theme  `yaml:"theme,omitempty"`
+	// Production holds the glob or /regex/ patterns of the context and cluster names painted with the production color.
+	Production []string `yaml:"production,omitempty"`
+	Backup     Backup   `yaml:"backup,omitempty"`
+}
+
+// Theme holds the colors of the table output, e.g. "green", "bold red" or "none".
+// An empty color keeps the default one.
+type Theme struct {
+	Current     string `yaml:"current,omitempty"`
+	Expired     string `yaml:"expired,omitempty"`
+	Expiring    string `yaml:"expiring,omitempty"`
+	Unreachable string `yaml:"unreachable,omitempty"`
+	Production  string `yaml:"production,omitempty"`
+}
+
+// Backup holds where the copies of the modified kubeconfig files and the trash of the deleted entries are
+// kept, and how long. A negative limit, or "-1" for the size and the age, disables it.
+type Backup struct {
+	// Dir is the backup directory, by default the directory of the kubeconfig file being modified.
+	Dir string `yaml:"dir,omitempty"`
+	// MaxCount is the number of copies kept per kubeconfig file, and of entries kept in the trash.
+	MaxCount int `yaml:"maxCount,omitempty"`
+	// MaxSize is the size the copies of a kubeconfig file, or the trash, are kept under, e.g. 10Mi.
+	MaxSize string `yaml:"maxSize,omitempty"`
+	// MaxAge is the age the copies and the entries of the trash are removed after, e.g. 90d or 12h.
+	MaxAge string `yaml:"maxAge,omitempty"`
+}
+
+// DefaultBackup is used for the limits the settings file doesn't set.
+var DefaultBackup = Backup{
+	MaxCount: 100,
+	MaxSize:  "10Mi",
+	MaxAge:   "90d",
+}
+
+// DefaultTheme is used for the colors the settings file doesn't set.
+var DefaultTheme = Theme{
+	Current:     "green",
+	Expired:     "red",
+	Expiring:    "yellow",
+	Unreachable: "red",
+	Production:  "bold magenta",
+}
+
+// Path returns the location of the settings file, or an empty string when no home directory is known.
+func Path() string {
+	if path := os.Getenv(EnvSettings); len(path) != 0 {
+		return path
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(homeDir, ".kube", settingsFileName)
+}
+
+// Load reads the settings file. Missing settings, or a missing file, fall back to the defaults.
+func Load() (*Settings, error) {
+	s := &Settings{}
+	path := Path()
+	if len(path) != 0 {
+		data, err := ioutil.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		if err := yaml.UnmarshalStrict(data, s); err != nil {
+			return nil, fmt.Errorf("invalid settings file %s: %v", path, err)
+		}
+	}
+
+	s.Theme = s.Theme.withDefaults()
+	s.Backup = s.Backup.withDefaults()
+	return s, nil
+}
+
+// MaxSizeBytes returns the size limit in bytes, or -1 when there is none.
+func (b Backup) MaxSizeBytes() (int64, error) {
+	if b.MaxSize == "-1" {
+		return -1, nil
+	}
+	size, err := resource.ParseQuantity(b.MaxSize)
+	if err != nil {
+		return 0, fmt.Errorf("invalid backup maxSize %q: %v", b.MaxSize, err)
+	}
+	return size.Value(), nil
+}
+
+// MaxAgeDuration returns the age limit, or -1 when there is none.
+func (b Backup) MaxAgeDuration() (time.Duration, error) {
+	if b.MaxAge == "-1" {
+		return -1, nil
+	}
+	age, err := ParseAge(b.MaxAge)
+	if err != nil {
+		return 0, fmt.Errorf("invalid backup maxAge %q: %v", b.MaxAge, err)
+	}
+	return age, nil
+}
+
+// ParseAge parses a duration like time.ParseDuration does, with a "d" suffix for days.
+func ParseAge(value string) (time.Duration, error) {
+	if strings.HasSuffix(value, "d") {
+		days, err := time.ParseDuration(strings.TrimSuffix(value, "d") + "h")
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		return days * 24, nil
+	}
+	return time.ParseDuration(value)
+}
+
+func (b Backup) withDefaults() Backup {
+	if dir := os.Getenv(EnvBackupDir); len(dir) != 0 {
+		b.Dir = dir
+	}
+	if b.MaxCount == 0 {
+		b.MaxCount = DefaultBackup.MaxCount
+	}
+	if len(b.MaxSize) == 0 {
+		b.MaxSize = DefaultBackup.MaxSize
+	}
+	if len(b.MaxAge) == 0 {
+		b.MaxAge = DefaultBackup.MaxAge
+	}
+	return b
+}
+
+func (t Theme) withDefaults() Theme {
+	if len(t.Current) == 0 {
+		t.Current = DefaultTheme.Current
+	}
+	if len(t.Expired) == 0 {
+		t.Expired = DefaultTheme.Expired
+	}
+	if len(t.Expiring) == 0 {
+		t.Expiring = DefaultTheme.Expiring
+	}
+	if len(t.Unreachable) == 0 {
+		t.Unreachable = DefaultTheme.Unreachable
+	}
+	if len(t.Production) == 0 {
+		t.Production = DefaultTheme.Production
+	}
+	return t
+}
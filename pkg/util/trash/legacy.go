@@ -0,0 +1,114 @@
+package trash
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v2"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// legacyFileName is the file the deleted entries were appended to before the trash, in the .kube directory.
+const legacyFileName = "kubectl-cfg-delete-bak.yaml"
+
+// legacyHeader starts each entry of the legacy file, with the time and the command of the deletion.
+var legacyHeader = regexp.MustCompile(`^# \[(.+)\] delete backup of '(.*)'$`)
+
+// legacyEntry is an entry of the legacy file, which holds the kubeconfig entry as dumped by yaml.v2.
+type legacyEntry struct {
+	Contexts struct {
+		Name    string
+		Context *clientcmdapi.Context
+	}
+	Clusters struct {
+		Name    string
+		Cluster *clientcmdapi.Cluster
+	}
+	Users struct {
+		Name string
+		User *clientcmdapi.AuthInfo
+	}
+}
+
+// legacyFile returns the path of the legacy file in the .kube directory of the home directory, and false
+// when there is no home directory, as a relative .kube directory would be the one of the working directory.
+func legacyFile() (string, bool) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil || len(homeDir) == 0 {
+		return "", false
+	}
+	return filepath.Join(homeDir, clientcmd.RecommendedHomeDir, legacyFileName), true
+}
+
+// migrateLegacy moves the entries of the legacy file into the trash, saves the trash and renames the legacy
+// file with a .migrated suffix, so that it is migrated once. The records are kept oldest first, the legacy
+// ones being given the next IDs. The entries which can't be read are left in the renamed file.
+func (s *Store) migrateLegacy(file string) error {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, chunk := range splitLegacy(data) {
+		s.addLegacy(chunk.header, chunk.content)
+	}
+	sort.SliceStable(s.Records, func(i, j int) bool { return s.Records[i].Deleted.Before(s.Records[j].Deleted) })
+
+	if err := s.Save(); err != nil {
+		return err
+	}
+	return os.Rename(file, file+".migrated")
+}
+
+// legacyChunk is the header line of an entry of the legacy file and the YAML which follows it.
+type legacyChunk struct {
+	header  []string
+	content []byte
+}
+
+// splitLegacy splits the legacy file into its entries, which are not separated as YAML documents.
+func splitLegacy(data []byte) []legacyChunk {
+	chunks := []legacyChunk{}
+	for _, line := range strings.Split(string(data), "\n") {
+		if header := legacyHeader.FindStringSubmatch(line); header != nil {
+			chunks = append(chunks, legacyChunk{header: header})
+			continue
+		}
+		if len(chunks) != 0 {
+			last := &chunks[len(chunks)-1]
+			last.content = append(last.content, line+"\n"...)
+		}
+	}
+	return chunks
+}
+
+// addLegacy adds the entry of a legacy chunk as a record deleted at the time of its header. The chunks
+// which don't hold an entry, like the ones of the names which didn't exist, are skipped.
+func (s *Store) addLegacy(header []string, content []byte) {
+	entry := legacyEntry{}
+	if err := yaml.Unmarshal(content, &entry); err != nil {
+		return
+	}
+	deleted, err := time.ParseInLocation("2006-01-02 15:04", header[1], time.Local)
+	if err != nil {
+		return
+	}
+
+	switch {
+	case entry.Contexts.Context != nil:
+		s.add(KindContext, entry.Contexts.Name, entry.Contexts.Context, header[2], deleted)
+	case entry.Clusters.Cluster != nil:
+		s.add(KindCluster, entry.Clusters.Name, entry.Clusters.Cluster, header[2], deleted)
+	case entry.Users.User != nil:
+		s.add(KindUser, entry.Users.Name, entry.Users.User, header[2], deleted)
+	}
+}
@@ -0,0 +1,153 @@
+package trash
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// legacyData is written like the versions before the trash did, with a backup of a missing name.
+const legacyData = `# [2020-01-01 10:00] delete backup of 'kubectl cfg delete cluster prod'
+clusters:
+  cluster:
+    locationoforigin: /home/config
+    server: https://127.0.0.1:1
+    insecureskiptlsverify: false
+    certificateauthority: ""
+    certificateauthoritydata:
+    - 99
+    - 97
+    extensions: {}
+  name: prod
+
+# [2020-01-02 11:30] delete backup of 'kubectl cfg delete cluster gone'
+clusters:
+  cluster: null
+  name: gone
+
+# [2020-01-03 12:00] delete backup of 'kubectl cfg delete auth admin'
+users:
+  name: admin
+  user:
+    locationoforigin: /home/config
+    token: secret
+    authprovider: null
+    exec: null
+    extensions: {}
+
+# [2020-01-04 09:00] delete backup of 'kubectl cfg delete context prod'
+contexts:
+  context:
+    locationoforigin: /home/config
+    cluster: prod
+    authinfo: admin
+    namespace: ns
+    extensions: {}
+  name: prod
+
+`
+
+func TestSplitLegacy(t *testing.T) {
+	chunks := splitLegacy([]byte("ignored\n" + legacyData))
+	commands := []string{}
+	for _, chunk := range chunks {
+		commands = append(commands, chunk.header[2])
+	}
+	expected := []string{
+		"kubectl cfg delete cluster prod",
+		"kubectl cfg delete cluster gone",
+		"kubectl cfg delete auth admin",
+		"kubectl cfg delete context prod",
+	}
+	if !reflect.DeepEqual(commands, expected) {
+		t.Errorf("expected the commands %v, got %v", expected, commands)
+	}
+}
+
+func TestMigrateLegacy(t *testing.T) {
+	dir := t.TempDir()
+	legacy := filepath.Join(dir, legacyFileName)
+	if err := ioutil.WriteFile(legacy, []byte(legacyData), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// a record of the trash deleted after the legacy entries is listed after them
+	s, err := Load(filepath.Join(dir, fileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Add(KindUser, "dev", &clientcmdapi.AuthInfo{Token: "dev"}, "kubectl cfg delete auth dev"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.migrateLegacy(legacy); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(legacy); !os.IsNotExist(err) {
+		t.Errorf("expected the legacy file to be renamed, got %v", err)
+	}
+	if _, err := os.Stat(legacy + ".migrated"); err != nil {
+		t.Errorf("expected the legacy file to be kept as .migrated: %v", err)
+	}
+
+	s, err = Load(filepath.Join(dir, fileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	type summary struct {
+		id      int
+		kind    string
+		name    string
+		deleted string
+		command string
+	}
+	summaries := []summary{}
+	for _, r := range s.Records {
+		summaries = append(summaries, summary{r.ID, r.Kind, r.Name, r.Deleted.In(time.Local).Format("2006-01-02 15:04"), r.Command})
+	}
+	expected := []summary{
+		{2, KindCluster, "prod", "2020-01-01 10:00", "kubectl cfg delete cluster prod"},
+		{3, KindUser, "admin", "2020-01-03 12:00", "kubectl cfg delete auth admin"},
+		{4, KindContext, "prod", "2020-01-04 09:00", "kubectl cfg delete context prod"},
+		{1, KindUser, "dev", s.Records[3].Deleted.In(time.Local).Format("2006-01-02 15:04"), "kubectl cfg delete auth dev"},
+	}
+	if !reflect.DeepEqual(summaries, expected) {
+		t.Errorf("expected the records %v, got %v", expected, summaries)
+	}
+	if s.LastID != 4 {
+		t.Errorf("expected the last ID 4, got %d", s.LastID)
+	}
+
+	entry, err := s.Records[0].Entry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cluster := entry.(*clientcmdapi.Cluster)
+	if cluster.Server != "https://127.0.0.1:1" || string(cluster.CertificateAuthorityData) != "ca" || s.Records[0].Source != "/home/config" {
+		t.Errorf("expected the legacy cluster to be restored as deleted, got %v from %q", cluster, s.Records[0].Source)
+	}
+	entry, err = s.Records[2].Entry()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if context := entry.(*clientcmdapi.Context); context.Cluster != "prod" || context.AuthInfo != "admin" || context.Namespace != "ns" {
+		t.Errorf("expected the legacy context to be restored as deleted, got %v", context)
+	}
+
+	// the migration is done once
+	if err := s.migrateLegacy(legacy); err != nil || len(s.Records) != 4 {
+		t.Errorf("expected nothing to migrate again, got %d records, %v", len(s.Records), err)
+	}
+}
+
+func TestLegacyFileWithoutHome(t *testing.T) {
+	t.Setenv("HOME", "")
+	if file, ok := legacyFile(); ok {
+		t.Errorf("expected no legacy file without a home directory, got %s", file)
+	}
+}
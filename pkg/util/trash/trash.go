@@ -0,0 +1,287 @@
+package trash
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ghodss/yaml"
+	kubeyaml "github.com/it2911/kubectl-cfg/pkg/util/yaml"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	clientcmdlatest "k8s.io/client-go/tools/clientcmd/api/latest"
+	clientcmdapiv1 "k8s.io/client-go/tools/clientcmd/api/v1"
+)
+
+// The kinds of the entries of the trash, named like in a kubeconfig.
+const (
+	KindContext = "context"
+	KindCluster = "cluster"
+	KindUser    = "user"
+)
+
+// fileName is the name of the trash file in the backup directory.
+const fileName = "kubectl-cfg-trash.yaml"
+
+// Record is an entry deleted from a kubeconfig.
+type Record struct {
+	ID      int       `json:"id"`
+	Deleted time.Time `json:"deleted"`
+	Kind    string    `json:"kind"`
+	Name    string    `json:"name"`
+	// Source is the kubeconfig file the entry was deleted from.
+	Source string `json:"source,omitempty"`
+	// Command is the command which deleted the entry.
+	Command string `json:"command,omitempty"`
+	// Content is the entry as written in a kubeconfig.
+	Content json.RawMessage `json:"content"`
+}
+
+// Store holds the records of the trash file, oldest first.
+type Store struct {
+	// LastID is the ID of the last record ever added, so that the IDs of purged records are not reused.
+	LastID  int      `json:"lastID"`
+	Records []Record `json:"records"`
+
+	file     string
+	location *kubeyaml.Location
+}
+
+// Open loads the trash kept in the backup location of the kubeconfig file which configAccess writes, the
+// records beyond the limits of the location being dropped when it is saved. A kubeconfig read from the
+// standard input or from base64 has no file, its trash is the one of the recommended kubeconfig file. The
+// entries of the file of the deleted entries of the versions before the trash are moved into it first.
+func Open(configAccess clientcmd.ConfigAccess) (*Store, error) {
+	file := configAccess.GetDefaultFilename()
+	if configAccess.IsExplicitFile() {
+		file = configAccess.GetExplicitFile()
+	}
+	if len(file) == 0 {
+		file = clientcmd.RecommendedHomeFile
+	}
+	location, err := kubeyaml.BackupLocation(file)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := Load(filepath.Join(location.Dir, fileName))
+	if err != nil {
+		return nil, err
+	}
+	s.location = location
+	if legacy, ok := legacyFile(); ok {
+		if err := s.migrateLegacy(legacy); err != nil {
+			return nil, fmt.Errorf("error moving the entries of %s into the trash: %v", legacy, err)
+		}
+	}
+	return s, nil
+}
+
+// Load loads a trash file, which is empty when the file doesn't exist yet.
+func Load(file string) (*Store, error) {
+	s := &Store{file: file}
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return s, nil
+		}
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("error reading the trash %s: %v", file, err)
+	}
+	return s, nil
+}
+
+// File returns the path of the trash file.
+func (s *Store) File() string {
+	return s.file
+}
+
+// Save writes the trash file, without the oldest records beyond the limits of its location.
+func (s *Store) Save() error {
+	if s.location != nil {
+		s.expire()
+	}
+	data, err := yaml.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return kubeyaml.WritePrivateFile(s.file, data)
+}
+
+// expire drops the oldest records beyond the limits of the location.
+func (s *Store) expire() {
+	times := make([]time.Time, len(s.Records))
+	sizes := make([]int64, len(s.Records))
+	for i, r := range s.Records {
+		data, _ := json.Marshal(r)
+		times[i], sizes[i] = r.Deleted, int64(len(data))
+	}
+	s.Records = s.Records[s.location.Expired(times, sizes):]
+}
+
+// Add records an entry deleted by command. entry is a *clientcmdapi.Context, Cluster or AuthInfo, whose
+// location of origin is the source of the record.
+func (s *Store) Add(kind, name string, entry interface{}, command string) (*Record, error) {
+	return s.add(kind, name, entry, command, time.Now())
+}
+
+func (s *Store) add(kind, name string, entry interface{}, command string, deleted time.Time) (*Record, error) {
+	content, source, err := encode(kind, name, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	s.LastID++
+	s.Records = append(s.Records, Record{
+		ID:      s.LastID,
+		Deleted: deleted.UTC().Truncate(time.Second),
+		Kind:    kind,
+		Name:    name,
+		Source:  source,
+		Command: command,
+		Content: content,
+	})
+	return &s.Records[len(s.Records)-1], nil
+}
+
+// CommandLine returns the command line cmd was run with, as the command of the records.
+func CommandLine(cmd *cobra.Command) string {
+	words := []string{"kubectl", cmd.Root().Name()}
+	for _, arg := range os.Args[1:] {
+		if strings.ContainsAny(arg, " \t\n'\"\\$*?[]{}()<>|&;`~#") {
+			arg = "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+		}
+		words = append(words, arg)
+	}
+	return strings.Join(words, " ")
+}
+
+// Find returns the record of an ID, or the latest record of each kind named name. kind restricts the
+// records to one kind when it is not empty.
+func (s *Store) Find(idOrName, kind string) []Record {
+	if id, err := strconv.Atoi(idOrName); err == nil {
+		for _, r := range s.Records {
+			if r.ID == id && (len(kind) == 0 || r.Kind == kind) {
+				return []Record{r}
+			}
+		}
+	}
+
+	latest := map[string]Record{}
+	for _, r := range s.Records {
+		if r.Name == idOrName && (len(kind) == 0 || r.Kind == kind) {
+			latest[r.Kind] = r
+		}
+	}
+	records := []Record{}
+	for _, r := range latest {
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+	return records
+}
+
+// Remove removes the records of ids.
+func (s *Store) Remove(ids ...int) {
+	removed := map[int]bool{}
+	for _, id := range ids {
+		removed[id] = true
+	}
+	records := []Record{}
+	for _, r := range s.Records {
+		if !removed[r.ID] {
+			records = append(records, r)
+		}
+	}
+	s.Records = records
+}
+
+// Entry decodes the content of the record into a *clientcmdapi.Context, Cluster or AuthInfo.
+func (r *Record) Entry() (interface{}, error) {
+	v1Config := &clientcmdapiv1.Config{}
+	var err error
+	switch r.Kind {
+	case KindContext:
+		named := clientcmdapiv1.NamedContext{Name: r.Name}
+		err = json.Unmarshal(r.Content, &named.Context)
+		v1Config.Contexts = append(v1Config.Contexts, named)
+	case KindCluster:
+		named := clientcmdapiv1.NamedCluster{Name: r.Name}
+		err = json.Unmarshal(r.Content, &named.Cluster)
+		v1Config.Clusters = append(v1Config.Clusters, named)
+	case KindUser:
+		named := clientcmdapiv1.NamedAuthInfo{Name: r.Name}
+		err = json.Unmarshal(r.Content, &named.AuthInfo)
+		v1Config.AuthInfos = append(v1Config.AuthInfos, named)
+	default:
+		return nil, fmt.Errorf("unknown kind %q of record %d", r.Kind, r.ID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading record %d: %v", r.ID, err)
+	}
+
+	config := clientcmdapi.NewConfig()
+	if err := clientcmdlatest.Scheme.Convert(v1Config, config, nil); err != nil {
+		return nil, err
+	}
+	switch r.Kind {
+	case KindContext:
+		return config.Contexts[r.Name], nil
+	case KindCluster:
+		return config.Clusters[r.Name], nil
+	default:
+		return config.AuthInfos[r.Name], nil
+	}
+}
+
+// encode converts an entry to the content of a record, through the conversion of a kubeconfig holding
+// only the entry, and returns its location of origin.
+func encode(kind, name string, entry interface{}) (json.RawMessage, string, error) {
+	config := clientcmdapi.NewConfig()
+	source := ""
+	switch e := entry.(type) {
+	case *clientcmdapi.Context:
+		if e != nil && kind == KindContext {
+			config.Contexts[name], source = e, e.LocationOfOrigin
+		}
+	case *clientcmdapi.Cluster:
+		if e != nil && kind == KindCluster {
+			config.Clusters[name], source = e, e.LocationOfOrigin
+		}
+	case *clientcmdapi.AuthInfo:
+		if e != nil && kind == KindUser {
+			config.AuthInfos[name], source = e, e.LocationOfOrigin
+		}
+	}
+	if len(config.Contexts)+len(config.Clusters)+len(config.AuthInfos) == 0 {
+		return nil, "", fmt.Errorf("no %s %s to put in the trash", kind, name)
+	}
+
+	v1Config := &clientcmdapiv1.Config{}
+	if err := clientcmdlatest.Scheme.Convert(config, v1Config, nil); err != nil {
+		return nil, "", err
+	}
+	var content interface{}
+	switch {
+	case len(v1Config.Contexts) != 0:
+		content = v1Config.Contexts[0].Context
+	case len(v1Config.Clusters) != 0:
+		content = v1Config.Clusters[0].Cluster
+	default:
+		content = v1Config.AuthInfos[0].AuthInfo
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return nil, "", err
+	}
+	return data, source, nil
+}
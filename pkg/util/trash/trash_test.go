@@ -0,0 +1,104 @@
+package trash
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestStore(t *testing.T) {
+	file := filepath.Join(t.TempDir(), fileName)
+	s, err := Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cluster := &clientcmdapi.Cluster{LocationOfOrigin: "/home/config", Server: "https://127.0.0.1:1", CertificateAuthorityData: []byte("ca")}
+	authInfo := &clientcmdapi.AuthInfo{Token: "token"}
+	context := &clientcmdapi.Context{Cluster: "prod", AuthInfo: "admin", Namespace: "ns"}
+	adds := []struct {
+		kind, name string
+		entry      interface{}
+	}{
+		{KindCluster, "prod", cluster},
+		{KindUser, "admin", authInfo},
+		{KindContext, "prod", context},
+		{KindContext, "prod", &clientcmdapi.Context{Cluster: "other"}},
+	}
+	for i, add := range adds {
+		r, err := s.Add(add.kind, add.name, add.entry, "kubectl cfg delete")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r.ID != i+1 {
+			t.Errorf("expected ID %d for %s %s, got %d", i+1, add.kind, add.name, r.ID)
+		}
+	}
+	if r, _ := s.Add(KindCluster, "nil", (*clientcmdapi.Cluster)(nil), ""); r != nil {
+		t.Errorf("expected no record of a nil entry, got %v", r)
+	}
+	if r, _ := s.Add(KindUser, "mismatch", cluster, ""); r != nil {
+		t.Errorf("expected no record of an entry of another kind, got %v", r)
+	}
+
+	// the IDs of the removed records are not reused, also once saved and loaded again
+	s.Remove(4)
+	if err := s.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if s, err = Load(file); err != nil {
+		t.Fatal(err)
+	}
+	if r, err := s.Add(KindUser, "dev", authInfo, ""); err != nil || r.ID != 5 {
+		t.Errorf("expected ID 5 after a removal, got %v, %v", r, err)
+	}
+
+	tests := []struct {
+		idOrName string
+		kind     string
+		expected []int
+	}{
+		{idOrName: "2", expected: []int{2}},
+		{idOrName: "2", kind: KindCluster},
+		{idOrName: "4"},
+		{idOrName: "prod", expected: []int{1, 3}},
+		{idOrName: "prod", kind: KindContext, expected: []int{3}},
+		{idOrName: "missing"},
+	}
+	for _, test := range tests {
+		ids := []int{}
+		for _, r := range s.Find(test.idOrName, test.kind) {
+			ids = append(ids, r.ID)
+		}
+		if len(ids) != len(test.expected) || (len(ids) != 0 && !reflect.DeepEqual(ids, test.expected)) {
+			t.Errorf("Find(%q, %q): expected %v, got %v", test.idOrName, test.kind, test.expected, ids)
+		}
+	}
+
+	// the entries come back as they were deleted, from where they were deleted
+	for _, r := range s.Find("1", "") {
+		entry, err := r.Entry()
+		if err != nil {
+			t.Fatal(err)
+		}
+		restored := entry.(*clientcmdapi.Cluster)
+		if restored.Server != cluster.Server || string(restored.CertificateAuthorityData) != "ca" {
+			t.Errorf("expected cluster %v, got %v", cluster, restored)
+		}
+		if r.Source != "/home/config" {
+			t.Errorf("expected the source /home/config, got %q", r.Source)
+		}
+	}
+	for _, r := range s.Find("3", "") {
+		entry, err := r.Entry()
+		if err != nil {
+			t.Fatal(err)
+		}
+		restored := entry.(*clientcmdapi.Context)
+		if restored.Cluster != "prod" || restored.AuthInfo != "admin" || restored.Namespace != "ns" {
+			t.Errorf("expected context %v, got %v", context, restored)
+		}
+	}
+}
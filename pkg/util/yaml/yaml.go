@@ -1,65 +1,177 @@
 package yaml
 
 import (
+	"crypto/sha256"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
-	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
 	"time"
 
-	"gopkg.in/yaml.v2"
+	"github.com/it2911/kubectl-cfg/pkg/util/settings"
 )
 
-func WriteYaml(errOut io.Writer, i interface{}, op, name string) error {
-	s, err := yaml.Marshal(i)
+const (
+	// backupPrefix starts the names of the copies of the kubeconfig files.
+	backupPrefix = "kubectl-cfg-backup-"
+
+	// backupTimeFormat is the time in the names of the copies, precise enough for the copies taken within
+	// the same second not to overwrite each other.
+	backupTimeFormat = "20060102-150405.000000000"
+)
+
+// Location is where the backups of a kubeconfig file are kept, and how long. A negative limit disables it.
+type Location struct {
+	Dir      string
+	MaxCount int
+	MaxSize  int64
+	MaxAge   time.Duration
+}
+
+// BackupLocation returns the location of the backups of a kubeconfig file, which is the backup directory of
+// the settings or the directory of the file, with the limits of the settings. The directory is created
+// when it doesn't exist.
+func BackupLocation(file string) (*Location, error) {
+	s, err := settings.Load()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	l := &Location{Dir: s.Backup.Dir, MaxCount: s.Backup.MaxCount}
+	if l.MaxSize, err = s.Backup.MaxSizeBytes(); err != nil {
+		return nil, err
+	}
+	if l.MaxAge, err = s.Backup.MaxAgeDuration(); err != nil {
+		return nil, err
 	}
 
+	if len(l.Dir) == 0 {
+		if len(file) == 0 {
+			return nil, fmt.Errorf("no kubeconfig file to keep the backups next to, set a backup directory with $%s", settings.EnvBackupDir)
+		}
+		l.Dir = filepath.Dir(file)
+	}
+	if err := os.MkdirAll(l.Dir, 0700); err != nil {
+		return nil, fmt.Errorf("cannot create the backup directory: %v", err)
+	}
+	return l, nil
+}
 
-	fileName := getKubeDir(errOut) + "/kubectl-cfg-delete-bak.yaml"
-	f, err := os.OpenFile(fileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0664)
-	defer f.Close()
+// Expired returns how many of the oldest backups exceed the limits, the backups being given by their times
+// and sizes from the oldest to the newest. The newest backup is always kept.
+func (l *Location) Expired(times []time.Time, sizes []int64) int {
+	expired := 0
+	if l.MaxAge >= 0 {
+		deadline := time.Now().Add(-l.MaxAge)
+		for expired < len(times) && times[expired].Before(deadline) {
+			expired++
+		}
+	}
+	if l.MaxCount >= 0 && len(times)-expired > l.MaxCount {
+		expired = len(times) - l.MaxCount
+	}
+	if l.MaxSize >= 0 {
+		total := int64(0)
+		for _, size := range sizes[expired:] {
+			total += size
+		}
+		for ; total > l.MaxSize && expired < len(sizes); expired++ {
+			total -= sizes[expired]
+		}
+	}
 
-	if err != nil {
-		fmt.Fprint(errOut, err.Error())
-		return err
+	if expired >= len(times) && len(times) != 0 {
+		expired = len(times) - 1
 	}
+	return expired
+}
 
-	time := time.Now().Format("2006-01-02 15:04")
-	commentary := fmt.Sprintf("# [%s] delete backup of 'kubectl cfg delete %s %s'\n", time, op, name)
-	if _, err = f.WriteString(commentary); err != nil {
-		fmt.Println(err.Error())
-		return err
+// BackupFile copies a kubeconfig file into its backup location before it is modified, and returns the
+// path of the copy. The oldest copies of the file beyond the limits are removed, a failure to remove them
+// being reported on errOut. Nothing is copied when the file doesn't exist yet.
+func BackupFile(errOut io.Writer, file string) (string, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
 	}
 
-	if _, err = f.Write(s); err != nil {
-		fmt.Fprint(errOut, err.Error())
-		return err
+	l, err := BackupLocation(file)
+	if err != nil {
+		return "", err
+	}
+	suffix, err := backupSuffix(file)
+	if err != nil {
+		return "", err
+	}
+	fileName := filepath.Join(l.Dir, backupPrefix+time.Now().Format(backupTimeFormat)+suffix)
+	if err := WritePrivateFile(fileName, data); err != nil {
+		return "", err
 	}
 
-	if _, err = f.WriteString("\n"); err != nil {
-		fmt.Fprint(errOut, err.Error())
-		return err
+	// the copy is taken, the old copies which could not be removed are removed by the next backup
+	if err := l.rotate(suffix); err != nil {
+		fmt.Fprintf(errOut, "warning: the old backups of %s could not be removed: %v\n", file, err)
 	}
+	return fileName, nil
+}
 
-	return nil
+// backupSuffix ends the names of the copies of a kubeconfig file with a hash of its absolute path and its
+// base name, so that the files of the same name kept in a shared backup directory are told apart.
+func backupSuffix(file string) (string, error) {
+	path, err := filepath.Abs(file)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(path))
+	return fmt.Sprintf("-%x-%s", sum[:4], filepath.Base(path)), nil
 }
 
-func getKubeDir(errOut io.Writer) string {
-	homeDir, err := os.UserHomeDir()
+// rotate removes the oldest copies of a kubeconfig file, whose names end with suffix, beyond the limits.
+func (l *Location) rotate(suffix string) error {
+	infos, err := ioutil.ReadDir(l.Dir)
 	if err != nil {
-		panic(err)
+		return err
 	}
 
-	kubeDir := homeDir + "/.kube"
+	// the copies are named after the time they were taken, so that their names sort them by age
+	copies := []os.FileInfo{}
+	for _, info := range infos {
+		name := info.Name()
+		if info.Mode().IsRegular() && strings.HasPrefix(name, backupPrefix) && strings.HasSuffix(name, suffix) &&
+			len(name) == len(backupPrefix)+len(backupTimeFormat)+len(suffix) {
+			copies = append(copies, info)
+		}
+	}
+	sort.Slice(copies, func(i, j int) bool { return copies[i].Name() < copies[j].Name() })
 
-	if _, err := os.Stat(kubeDir); err != nil {
-		if os.IsNotExist(err) {
-			fmt.Fprint(errOut, "Error: .kube directory doesn't exist.")
-		} else {
-			fmt.Fprint(errOut, "Error: get .kube directory error.")
+	times := make([]time.Time, len(copies))
+	sizes := make([]int64, len(copies))
+	for i, info := range copies {
+		times[i], sizes[i] = info.ModTime(), info.Size()
+	}
+	for _, info := range copies[:l.Expired(times, sizes)] {
+		if err := os.Remove(filepath.Join(l.Dir, info.Name())); err != nil {
+			return err
 		}
 	}
+	return nil
+}
 
-	return kubeDir
-}
\ No newline at end of file
+// WritePrivateFile writes a file readable by its owner only, as the backups hold credentials. The file is
+// written to a temporary file first, so that an interrupted write doesn't truncate it.
+func WritePrivateFile(file string, data []byte) error {
+	tmp := file + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	// the temporary file may be left over by an interrupted write, with other permissions
+	if err := os.Chmod(tmp, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, file)
+}
@@ -0,0 +1,251 @@
+package yaml
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/it2911/kubectl-cfg/pkg/util/settings"
+)
+
+func TestExpired(t *testing.T) {
+	now := time.Now()
+	days := func(ages ...int) []time.Time {
+		times := []time.Time{}
+		for _, age := range ages {
+			times = append(times, now.Add(-time.Duration(age)*24*time.Hour))
+		}
+		return times
+	}
+	tests := []struct {
+		name     string
+		location Location
+		times    []time.Time
+		sizes    []int64
+		expected int
+	}{
+		{
+			name:     "within the limits",
+			location: Location{MaxCount: 3, MaxSize: 100, MaxAge: 10 * 24 * time.Hour},
+			times:    days(3, 2, 1),
+			sizes:    []int64{10, 10, 10},
+		},
+		{
+			name:     "beyond the count",
+			location: Location{MaxCount: 2, MaxSize: -1, MaxAge: -1},
+			times:    days(3, 2, 1),
+			sizes:    []int64{10, 10, 10},
+			expected: 1,
+		},
+		{
+			name:     "beyond the size",
+			location: Location{MaxCount: -1, MaxSize: 25, MaxAge: -1},
+			times:    days(3, 2, 1),
+			sizes:    []int64{10, 10, 10},
+			expected: 1,
+		},
+		{
+			name:     "beyond the age",
+			location: Location{MaxCount: -1, MaxSize: -1, MaxAge: 5 * 24 * time.Hour},
+			times:    days(9, 7, 3, 1),
+			sizes:    []int64{10, 10, 10, 10},
+			expected: 2,
+		},
+		{
+			name:     "the newest is kept beyond every limit",
+			location: Location{MaxCount: 0, MaxSize: 5, MaxAge: time.Hour},
+			times:    days(3, 2, 1),
+			sizes:    []int64{10, 10, 10},
+			expected: 2,
+		},
+		{
+			name:     "disabled limits",
+			location: Location{MaxCount: -1, MaxSize: -1, MaxAge: -1},
+			times:    days(300, 200, 100),
+			sizes:    []int64{1 << 30, 1 << 30, 1 << 30},
+		},
+		{
+			name:     "nothing to expire",
+			location: Location{MaxCount: 1, MaxSize: 1, MaxAge: time.Hour},
+		},
+	}
+
+	for _, test := range tests {
+		if expired := test.location.Expired(test.times, test.sizes); expired != test.expected {
+			t.Errorf("%s: expected %d expired, got %d", test.name, test.expected, expired)
+		}
+	}
+}
+
+// useBackupSettings points the settings at a file with the backup settings, in a temporary directory.
+func useBackupSettings(t *testing.T, backup string) {
+	path := filepath.Join(t.TempDir(), "settings.yaml")
+	if err := ioutil.WriteFile(path, []byte("backup:\n"+backup), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(settings.EnvSettings, path)
+	t.Setenv(settings.EnvBackupDir, "")
+}
+
+// backups returns the sorted names of the copies in dir.
+func backups(t *testing.T, dir string) []string {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, info := range infos {
+		if strings.HasPrefix(info.Name(), backupPrefix) {
+			names = append(names, info.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestBackupFile(t *testing.T) {
+	useBackupSettings(t, "  maxCount: 2\n")
+	dir := t.TempDir()
+	file := filepath.Join(dir, "config")
+	unrelated := filepath.Join(dir, "notes.txt")
+	if err := ioutil.WriteFile(unrelated, []byte("notes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	copies := []string{}
+	for _, content := range []string{"first", "second", "third"} {
+		if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		errOut := &bytes.Buffer{}
+		backup, err := BackupFile(errOut, file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if errOut.Len() != 0 {
+			t.Errorf("unexpected warning: %s", errOut)
+		}
+		if filepath.Dir(backup) != dir {
+			t.Errorf("expected the copy next to the file, got %s", backup)
+		}
+		info, err := os.Stat(backup)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("expected the copy to be private, got %v", info.Mode().Perm())
+		}
+		copies = append(copies, filepath.Base(backup))
+	}
+
+	// the copies taken in the same second are kept apart, and the oldest beyond the count is removed
+	if names := backups(t, dir); len(names) != 2 || names[0] != copies[1] || names[1] != copies[2] {
+		t.Errorf("expected the copies %v, got %v", copies[1:], names)
+	}
+	if data, err := ioutil.ReadFile(filepath.Join(dir, copies[2])); err != nil || string(data) != "third" {
+		t.Errorf("expected the last copy to hold the third content, got %q, %v", data, err)
+	}
+	if _, err := os.Stat(unrelated); err != nil {
+		t.Errorf("expected the unrelated file to be left alone: %v", err)
+	}
+}
+
+func TestBackupFileMissing(t *testing.T) {
+	useBackupSettings(t, "")
+	backup, err := BackupFile(ioutil.Discard, filepath.Join(t.TempDir(), "config"))
+	if err != nil || len(backup) != 0 {
+		t.Errorf("expected no copy of a missing file, got %q, %v", backup, err)
+	}
+}
+
+func TestBackupFileSharedDirectory(t *testing.T) {
+	useBackupSettings(t, "  maxCount: 1\n")
+	backupDir := t.TempDir()
+	t.Setenv(settings.EnvBackupDir, backupDir)
+
+	// two kubeconfig files named alike don't rotate the copies of each other away
+	files := []string{filepath.Join(t.TempDir(), "config"), filepath.Join(t.TempDir(), "config")}
+	for _, file := range files {
+		if err := ioutil.WriteFile(file, []byte(file), 0600); err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 2; i++ {
+			if _, err := BackupFile(ioutil.Discard, file); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	names := backups(t, backupDir)
+	if len(names) != 2 {
+		t.Fatalf("expected one copy of each file, got %v", names)
+	}
+	for i, file := range files {
+		suffix, err := backupSuffix(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		found := false
+		for _, name := range names {
+			if strings.HasSuffix(name, suffix) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected a copy of file %d ending with %s, got %v", i, suffix, names)
+		}
+	}
+}
+
+func TestRotate(t *testing.T) {
+	dir := t.TempDir()
+	suffix, err := backupSuffix(filepath.Join(dir, "config"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	write := func(name string, age time.Duration) {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte("copy"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, now.Add(-age), now.Add(-age)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	name := func(age time.Duration) string {
+		return backupPrefix + now.Add(-age).Format(backupTimeFormat) + suffix
+	}
+
+	old, recent := name(48*time.Hour), name(time.Hour)
+	write(old, 48*time.Hour)
+	write(recent, time.Hour)
+	// the old copies of other files, and the files which are not copies, are left alone
+	others := []string{
+		backupPrefix + now.Add(-48*time.Hour).Format(backupTimeFormat) + "-00000000-config",
+		backupPrefix + "notes" + suffix,
+		"config",
+	}
+	for _, other := range others {
+		write(other, 48*time.Hour)
+	}
+
+	l := &Location{Dir: dir, MaxCount: -1, MaxSize: -1, MaxAge: 24 * time.Hour}
+	if err := l.rotate(suffix); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, old)); !os.IsNotExist(err) {
+		t.Errorf("expected the old copy to be removed, got %v", err)
+	}
+	for _, kept := range append(others, recent) {
+		if _, err := os.Stat(filepath.Join(dir, kept)); err != nil {
+			t.Errorf("expected %s to be kept: %v", kept, err)
+		}
+	}
+}